@@ -4,20 +4,57 @@ import "reflect"
 
 // Config[T] is the configuration for reading environment variables.
 type Config[T any] struct {
-	UseEnvFile      bool                    // read from an environment file on disk?
-	EnvFilePath     string                  // custom path to the environment file (otherwise search for ".env")
-	EnvFileOverride bool                    // should variables found in the env file override environment variables?
-	Validators      map[string]Validator    // a map of custom validators to be used by the loader
-	Parsers         map[reflect.Type]Parser // a map of custom type parsers to be used by the loader
-	DefaultValue    *T                      // default values to use if they do not exist in the environment
+	UseEnvFile           bool                            // read from an environment file on disk?
+	EnvFilePath          string                          // custom path to the environment file (otherwise search for ".env")
+	EnvFiles             []string                        // ordered list of environment files to layer, later files override earlier ones (overrides EnvFilePath)
+	EnvProfile           string                          // the profile used to replace {profile} in EnvFiles
+	EnvProfileVar        string                          // the variable to read the profile from if EnvProfile is not set (like APP_ENV)
+	EnvFileOverride      bool                            // should variables found in the env file override environment variables?
+	EnvFileOverlay       bool                            // keep variables found in the env file in memory instead of adding them to the environment?
+	ExpandVariables      bool                            // expand references to variables (${NAME}) in the env file and default tags?
+	StrictExpansion      bool                            // return an error when expanding a reference to a variable that does not exist?
+	UseFileSuffix        bool                            // read the value from the file named by NAME_FILE if NAME does not exist?
+	UseBinaryUnmarshaler bool                            // parse types that implement encoding.BinaryUnmarshaler?
+	Validators           map[string]Validator            // a map of custom validators to be used by the loader
+	ValueValidators      map[string]ValueValidator       // a map of custom validators of parsed values to be used by the loader
+	TypeValidators       map[reflect.Type]ValueValidator // validators of parsed values to run on every field of the type
+	Parsers              map[reflect.Type]Parser         // a map of custom type parsers to be used by the loader
+	DefaultValue         *T                              // default values to use if they do not exist in the environment
+	Sources              []Source                        // ordered list of sources to read values from, first match wins (otherwise the process environment)
+}
+
+// DefaultEnvFiles is the conventional list of layered environment files to use in [Config.EnvFiles].
+var DefaultEnvFiles = []string{".env", ".env.local", ".env.{profile}", ".env.{profile}.local"}
+
+// sources will return the ordered list of sources to read values from.
+func (cfg Config[T]) sources() []Source {
+	if len(cfg.Sources) == 0 {
+		return []Source{EnvSource{}}
+	}
+	return cfg.Sources
+}
+
+// overlay will check if the variables from the environment file are kept in memory instead of being added to the
+// process environment (always the case with custom sources as they may not read the process environment).
+func (cfg Config[T]) overlay() bool {
+	return cfg.EnvFileOverlay || len(cfg.Sources) > 0
 }
 
 // DefaultConfig will create a new [Config] with the default values.
 func DefaultConfig[T any]() Config[T] {
 	return Config[T]{
-		UseEnvFile:      true,
-		EnvFilePath:     "",
-		EnvFileOverride: false,
-		DefaultValue:    nil,
+		UseEnvFile:           true,
+		EnvFilePath:          "",
+		EnvFiles:             nil,
+		EnvProfile:           "",
+		EnvProfileVar:        "",
+		EnvFileOverride:      false,
+		EnvFileOverlay:       false,
+		ExpandVariables:      false,
+		StrictExpansion:      false,
+		UseFileSuffix:        true,
+		UseBinaryUnmarshaler: false,
+		DefaultValue:         nil,
+		Sources:              nil,
 	}
 }
@@ -0,0 +1,246 @@
+package confik
+
+import (
+	"cmp"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// constraint is a check on the parsed value of a field (like min=1 or oneof=a|b).
+//
+// The value is the raw value of the variable (used in error messages).
+type constraint func(fc *FieldConfig, value string, rv reflect.Value) error
+
+var durationType = reflect.TypeOf((*time.Duration)(nil)).Elem()
+
+// compileConstraints will compile the constraint settings of a field of type t.
+//
+// The options of oneof are parsed into the type of the field with parser.
+func compileConstraints(fc *FieldConfig, t reflect.Type, parser Parser) ([]constraint, error) {
+	ft := t
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var constraints []constraint
+	if fc.Min != nil {
+		c, err := compileBound("min", *fc.Min, t)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, c)
+	}
+	if fc.Max != nil {
+		c, err := compileBound("max", *fc.Max, t)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, c)
+	}
+	if fc.Len != nil {
+		c, err := compileLen(*fc.Len, t)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, c)
+	}
+	if fc.OneOf != nil {
+		c, err := compileOneOf(fc, fc.OneOf, ft, parser)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, c)
+	}
+	if fc.Regex != nil {
+		c, err := compileRegex(*fc.Regex, t)
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, c)
+	}
+	return constraints, nil
+}
+
+// checkConstraints will check the parsed value of a field against each of the constraints.
+//
+// Pointers are dereferenced and nil pointers are not checked.
+func checkConstraints(constraints []constraint, fc *FieldConfig, value string, rv reflect.Value) error {
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	for _, c := range constraints {
+		if err := c(fc, value, rv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasLength will check if the length of a value of type t can be constrained.
+func hasLength(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// lengthOf will return the length of a value (the number of characters in a string).
+func lengthOf(rv reflect.Value) int {
+	if rv.Kind() == reflect.String {
+		return utf8.RuneCountInString(rv.String())
+	}
+	return rv.Len()
+}
+
+// outOfBound will check if v is below the bound (for min) or above the bound (for max).
+func outOfBound[V cmp.Ordered](v V, bound V, isMin bool) bool {
+	if isMin {
+		return v < bound
+	}
+	return v > bound
+}
+
+// compileBound will compile the min or max setting of a field of type t.
+//
+// Numbers are compared numerically, durations by length and strings, slices and maps by their length.
+func compileBound(name string, arg string, t reflect.Type) (constraint, error) {
+	isMin := name == "min"
+	word := "at most"
+	if isMin {
+		word = "at least"
+	}
+	invalid := func(err error) error {
+		return fmt.Errorf("invalid %s %s for %s: %w", name, arg, t, err)
+	}
+
+	switch {
+	case t == durationType:
+		bound, err := time.ParseDuration(arg)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return func(fc *FieldConfig, value string, rv reflect.Value) error {
+			if outOfBound(time.Duration(rv.Int()), bound, isMin) {
+				return fmt.Errorf("%s=%s must be %s %s", fc.Name, value, word, bound)
+			}
+			return nil
+		}, nil
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		bound, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return func(fc *FieldConfig, value string, rv reflect.Value) error {
+			if outOfBound(rv.Int(), bound, isMin) {
+				return fmt.Errorf("%s=%s must be %s %s", fc.Name, value, word, arg)
+			}
+			return nil
+		}, nil
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uint64:
+		bound, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return func(fc *FieldConfig, value string, rv reflect.Value) error {
+			if outOfBound(rv.Uint(), bound, isMin) {
+				return fmt.Errorf("%s=%s must be %s %s", fc.Name, value, word, arg)
+			}
+			return nil
+		}, nil
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		bound, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return func(fc *FieldConfig, value string, rv reflect.Value) error {
+			if outOfBound(rv.Float(), bound, isMin) {
+				return fmt.Errorf("%s=%s must be %s %s", fc.Name, value, word, arg)
+			}
+			return nil
+		}, nil
+	case hasLength(t):
+		bound, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, invalid(err)
+		}
+		return func(fc *FieldConfig, value string, rv reflect.Value) error {
+			if outOfBound(lengthOf(rv), bound, isMin) {
+				return fmt.Errorf("%s=%s must have a length of %s %d", fc.Name, value, word, bound)
+			}
+			return nil
+		}, nil
+	}
+	return nil, fmt.Errorf("%s is not supported for %s", name, t)
+}
+
+// compileLen will compile the len setting of a field of type t.
+func compileLen(arg string, t reflect.Type) (constraint, error) {
+	if !hasLength(t) {
+		return nil, fmt.Errorf("len is not supported for %s", t)
+	}
+	length, err := strconv.Atoi(arg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid len %s for %s: %w", arg, t, err)
+	}
+	return func(fc *FieldConfig, value string, rv reflect.Value) error {
+		if lengthOf(rv) != length {
+			return fmt.Errorf("%s=%s must have a length of %d", fc.Name, value, length)
+		}
+		return nil
+	}, nil
+}
+
+// compileOneOf will compile the oneof setting of a field of type t.
+//
+// The options are parsed into the type of the field so they are compared with the parsed value (pointers are
+// dereferenced).
+func compileOneOf(fc *FieldConfig, options []string, t reflect.Type, parser Parser) (constraint, error) {
+	if parser == nil {
+		return nil, fmt.Errorf("oneof is not supported for %s", t)
+	}
+	values := make([]any, len(options))
+	for i, option := range options {
+		rv := reflect.New(t).Elem()
+		if err := parser(fc, option, rv); err != nil {
+			return nil, fmt.Errorf("invalid oneof option %s: %w", option, err)
+		}
+		for rv.Kind() == reflect.Pointer {
+			rv = rv.Elem()
+		}
+		values[i] = rv.Interface()
+	}
+	return func(fc *FieldConfig, value string, rv reflect.Value) error {
+		for _, v := range values {
+			if reflect.DeepEqual(rv.Interface(), v) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s=%s must be one of %s", fc.Name, value, strings.Join(options, ", "))
+	}, nil
+}
+
+// compileRegex will compile the regex setting of a field of type t.
+func compileRegex(pattern string, t reflect.Type) (constraint, error) {
+	if t.Kind() != reflect.String {
+		return nil, fmt.Errorf("regex is not supported for %s", t)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex %s: %w", pattern, err)
+	}
+	return func(fc *FieldConfig, value string, rv reflect.Value) error {
+		if !re.MatchString(rv.String()) {
+			return fmt.Errorf("%s=%s must match %s", fc.Name, value, pattern)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,118 @@
+package confik
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testConstraints struct {
+	LogLevel string         `env:"LOG_LEVEL,oneof=debug|info|warn"`
+	Workers  int            `env:"WORKERS,min=1,max=16"`
+	Ratio    float64        `env:"RATIO,max=1"`
+	Timeout  time.Duration  `env:"TIMEOUT,min=1s,max=1m"`
+	Code     string         `env:"CODE,len=3,regex=^[A-Z]+$"`
+	Hosts    []string       `env:"HOSTS,min=1,max=2"`
+	Limits   map[string]int `env:"LIMITS,optional,max=1"`
+	Retries  *uint          `env:"RETRIES,optional,max=5"`
+	Port     uint16         `env:"PORT,oneof=80|443"`
+	Mode     *string        `env:"MODE,optional,oneof=fast|safe"`
+}
+
+func TestLoadFromEnvConstraints(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("LOG_LEVEL", "info")
+	os.Setenv("WORKERS", "16")
+	os.Setenv("RATIO", "0.5")
+	os.Setenv("TIMEOUT", "30s")
+	os.Setenv("CODE", "ABC")
+	os.Setenv("HOSTS", "a,b")
+	os.Setenv("PORT", "443")
+	os.Setenv("MODE", "safe")
+	cfg, err := LoadFromEnv(Config[testConstraints]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "info", cfg.LogLevel)
+	assert.Equal(t, 16, cfg.Workers)
+	assert.Equal(t, 30*time.Second, cfg.Timeout)
+	assert.Nil(t, cfg.Retries)
+	assert.Equal(t, uint16(443), cfg.Port)
+	if assert.NotNil(t, cfg.Mode) {
+		assert.Equal(t, "safe", *cfg.Mode)
+	}
+}
+
+func TestLoadFromEnvConstraintErrors(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("LOG_LEVEL", "trace")
+	os.Setenv("WORKERS", "0")
+	os.Setenv("RATIO", "1.5")
+	os.Setenv("TIMEOUT", "2m")
+	os.Setenv("CODE", "ABCD")
+	os.Setenv("HOSTS", "a,b,c")
+	os.Setenv("LIMITS", "a:1,b:2")
+	os.Setenv("RETRIES", "6")
+	os.Setenv("PORT", "8080")
+	os.Setenv("MODE", "slow")
+	_, err := LoadFromEnv(Config[testConstraints]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrValidation)
+		assert.Equal(t, []string{
+			"LOG_LEVEL=trace must be one of debug, info, warn",
+			"WORKERS=0 must be at least 1",
+			"RATIO=1.5 must be at most 1",
+			"TIMEOUT=2m must be at most 1m0s",
+			"CODE=ABCD must have a length of 3",
+			"HOSTS=a,b,c must have a length of at most 2",
+			"LIMITS=a:1,b:2 must have a length of at most 1",
+			"RETRIES=6 must be at most 5",
+			"PORT=8080 must be one of 80, 443",
+			"MODE=slow must be one of fast, safe",
+		}, loadErrorMessages(t, err))
+	}
+
+	os.Setenv("CODE", "abc")
+	_, err = LoadFromEnv(Config[struct {
+		Code string `env:"CODE,regex=^[A-Z]+$"`
+	}]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "CODE=abc must match ^[A-Z]+$", err.Error())
+	}
+}
+
+type testInvalidConstraints struct {
+	Workers  int                   `env:"WORKERS,min=one"`
+	Timeout  time.Duration         `env:"TIMEOUT,max=forever"`
+	Enabled  bool                  `env:"ENABLED,min=1"`
+	Port     int                   `env:"PORT,len=2"`
+	Level    int                   `env:"LEVEL,oneof=low|high"`
+	Count    int                   `env:"COUNT,regex=^[0-9]+$"`
+	Name     string                `env:"NAME,regex=[a-z"`
+	Database struct{ Host string } `env:"DATABASE,len=1"`
+}
+
+func TestNewLoaderInvalidConstraints(t *testing.T) {
+	_, err := NewLoader(Config[testInvalidConstraints]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrInvalidTag)
+		assert.Equal(t, []string{
+			`invalid tag on field Workers: invalid min one for int: strconv.ParseInt: parsing "one": invalid syntax`,
+			`invalid tag on field Timeout: invalid max forever for time.Duration: time: invalid duration "forever"`,
+			"invalid tag on field Enabled: min is not supported for bool",
+			"invalid tag on field Port: len is not supported for int",
+			`invalid tag on field Level: invalid oneof option low: LEVEL=low is not a valid int: strconv.ParseInt: parsing "low": invalid syntax`,
+			"invalid tag on field Count: regex is not supported for int",
+			"invalid tag on field Name: invalid regex [a-z: error parsing regexp: missing closing ]: `[a-z`",
+			"invalid tag on field Database: constraints are not supported for nested structs",
+		}, loadErrorMessages(t, err))
+	}
+}
@@ -0,0 +1,220 @@
+package confik
+
+import (
+	"fmt"
+	"strings"
+)
+
+// envValue is the value of a variable in an environment file.
+type envValue struct {
+	Value   string // the (unquoted) value
+	Literal bool   // was the value single quoted or backtick quoted? (literal values are never expanded)
+	Path    string // path to the environment file containing the variable
+}
+
+// envParser is a parser for environment files using the common dotenv syntax (see [parseEnvValues]).
+type envParser struct {
+	input  []rune
+	pos    int // position of the next rune in the input
+	line   int // line of the next rune in the input
+	column int // column of the next rune in the input
+}
+
+// newEnvParser will create a new [envParser] for the input.
+func newEnvParser(input string) *envParser {
+	return &envParser{
+		input:  []rune(input),
+		pos:    0,
+		line:   1,
+		column: 1,
+	}
+}
+
+// errorAt will create an error for an invalid expression at the given line and column.
+func (p *envParser) errorAt(line int, column int, format string, args ...any) error {
+	return fmt.Errorf("invalid expression in env file at line %d, column %d: %s", line, column, fmt.Sprintf(format, args...))
+}
+
+// errorf will create an error for an invalid expression at the current position.
+func (p *envParser) errorf(format string, args ...any) error {
+	return p.errorAt(p.line, p.column, format, args...)
+}
+
+// peek will return the rune at offset from the current position (or 0 if there is no rune).
+func (p *envParser) peek(offset int) rune {
+	if p.pos+offset >= len(p.input) {
+		return 0
+	}
+	return p.input[p.pos+offset]
+}
+
+// done will check if all of the input has been consumed.
+func (p *envParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+// next will consume and return the next rune.
+func (p *envParser) next() rune {
+	r := p.input[p.pos]
+	p.pos++
+	if r == '\n' {
+		p.line++
+		p.column = 1
+	} else {
+		p.column++
+	}
+	return r
+}
+
+// skipSpace will consume spaces and tabs.
+func (p *envParser) skipSpace() {
+	for !p.done() && (p.peek(0) == ' ' || p.peek(0) == '\t') {
+		p.next()
+	}
+}
+
+// skipLine will consume everything up to and including the next newline.
+func (p *envParser) skipLine() {
+	for !p.done() {
+		if p.next() == '\n' {
+			return
+		}
+	}
+}
+
+// atLineEnd will check if the parser is at the end of a line (or the end of the input).
+func (p *envParser) atLineEnd() bool {
+	return p.done() || p.peek(0) == '\n' || (p.peek(0) == '\r' && p.peek(1) == '\n')
+}
+
+// isKeyRune will check if r can be used in the name of a variable.
+func isKeyRune(r rune) bool {
+	return r == '_' || r == '.' || r == '-' || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
+}
+
+// parse will parse all of the expressions in the input into a map[string]envValue.
+func (p *envParser) parse() (map[string]envValue, error) {
+	kv := make(map[string]envValue)
+	for {
+		p.skipSpace()
+		if p.done() {
+			return kv, nil
+		}
+		switch {
+		case p.atLineEnd() || p.peek(0) == '\r':
+			p.next()
+		case p.peek(0) == '#' || (p.peek(0) == '/' && p.peek(1) == '/'):
+			p.skipLine()
+		default:
+			key, value, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			kv[key] = *value
+		}
+	}
+}
+
+// parseKey will parse the name of a variable.
+func (p *envParser) parseKey() (string, error) {
+	var sb strings.Builder
+	for !p.done() && isKeyRune(p.peek(0)) {
+		sb.WriteRune(p.next())
+	}
+	if sb.Len() == 0 {
+		return "", p.errorf("expected variable name")
+	}
+	return sb.String(), nil
+}
+
+// parseExpression will parse a single expression in the format [export] NAME=VALUE [# comment].
+func (p *envParser) parseExpression() (string, *envValue, error) {
+	p.skipSpace()
+	key, err := p.parseKey()
+	if err != nil {
+		return "", nil, err
+	}
+
+	// handle "export NAME=VALUE"
+	if key == "export" && (p.peek(0) == ' ' || p.peek(0) == '\t') {
+		p.skipSpace()
+		if key, err = p.parseKey(); err != nil {
+			return "", nil, err
+		}
+	}
+
+	p.skipSpace()
+	if p.done() || p.peek(0) != '=' {
+		return "", nil, p.errorf("expected '=' after %s", key)
+	}
+	p.next()
+	p.skipSpace()
+
+	var value envValue
+	switch p.peek(0) {
+	case '"', '\'', '`':
+		value.Literal = p.peek(0) != '"'
+		if value.Value, err = p.parseQuoted(); err != nil {
+			return "", nil, err
+		}
+		p.skipSpace()
+	default:
+		value.Value = p.parseUnquoted()
+	}
+
+	// only a comment can follow the value
+	if !p.atLineEnd() && p.peek(0) != '#' {
+		return "", nil, p.errorf("unexpected character %q after value of %s", p.peek(0), key)
+	}
+	p.skipLine()
+	return key, &value, nil
+}
+
+// parseUnquoted will parse an unquoted value up to the end of the line or an inline comment.
+func (p *envParser) parseUnquoted() string {
+	var sb strings.Builder
+	var last rune = ' '
+	for !p.atLineEnd() {
+		// a comment must be separated from the value by whitespace
+		if p.peek(0) == '#' && (last == ' ' || last == '\t') {
+			break
+		}
+		last = p.next()
+		sb.WriteRune(last)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// parseQuoted will parse a value wrapped in double quotes, single quotes or backticks.
+//
+// Only double quoted values support escape sequences.
+func (p *envParser) parseQuoted() (string, error) {
+	line, column := p.line, p.column
+	quote := p.next()
+	var sb strings.Builder
+	for !p.done() {
+		r := p.next()
+		switch {
+		case r == quote:
+			return sb.String(), nil
+		case r == '\\' && quote == '"' && !p.done():
+			escaped := p.next()
+			switch escaped {
+			case 'n':
+				sb.WriteRune('\n')
+			case 'r':
+				sb.WriteRune('\r')
+			case 't':
+				sb.WriteRune('\t')
+			case '"', '\\':
+				sb.WriteRune(escaped)
+			default:
+				sb.WriteRune(r)
+				sb.WriteRune(escaped)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return "", p.errorAt(line, column, "unterminated quoted value")
+}
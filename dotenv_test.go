@@ -0,0 +1,73 @@
+package confik
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseEnvFileDotenv(t *testing.T) {
+	input := "# Comment\n" +
+		"export TC_EXPORT=exported\n" +
+		"export  TC_EXPORT_QUOTED = \"exported quoted\"\n" +
+		"TC_INLINE_COMMENT=value # comment\n" +
+		"TC_HASH=value#not-a-comment\n" +
+		"TC_EMPTY=\n" +
+		"TC_EMPTY_COMMENT= # comment\n" +
+		"TC_SINGLE='literal \\n $VALUE \"quoted\"' # comment\n" +
+		"TC_BACKTICK=`literal 'single' \"double\"`\n" +
+		"TC_DOUBLE=\"line\\nnext\\ttab \\\"quoted\\\" \\\\ \\x\"\n" +
+		"TC_MULTILINE=\"line 1\n" +
+		"line 2\"\n" +
+		"TC_MULTILINE_SINGLE='line 1\n" +
+		"# not a comment'\n" +
+		"TC_CRLF=crlf\r\n" +
+		"TC_DOTTED.NAME-1=dotted\n" +
+		"export=not exported\n"
+	values, err := newEnvParser(input).parse()
+	assert.Nil(t, err)
+	kv := envValuesToMap(values)
+	expected := map[string]string{
+		"TC_EXPORT":           "exported",
+		"TC_EXPORT_QUOTED":    "exported quoted",
+		"TC_INLINE_COMMENT":   "value",
+		"TC_HASH":             "value#not-a-comment",
+		"TC_EMPTY":            "",
+		"TC_EMPTY_COMMENT":    "",
+		"TC_SINGLE":           "literal \\n $VALUE \"quoted\"",
+		"TC_BACKTICK":         "literal 'single' \"double\"",
+		"TC_DOUBLE":           "line\nnext\ttab \"quoted\" \\ \\x",
+		"TC_MULTILINE":        "line 1\nline 2",
+		"TC_MULTILINE_SINGLE": "line 1\n# not a comment",
+		"TC_CRLF":             "crlf",
+		"TC_DOTTED.NAME-1":    "dotted",
+		"export":              "not exported",
+	}
+	assert.Equal(t, expected, kv)
+}
+
+func TestParseEnvFileDotenvErrors(t *testing.T) {
+	tests := map[string]string{
+		"=value":                     "invalid expression in env file at line 1, column 1: expected variable name",
+		"A=1\n\nB":                   "invalid expression in env file at line 3, column 2: expected '=' after B",
+		"A=1\nB=\"open\nC=2":         "invalid expression in env file at line 2, column 3: unterminated quoted value",
+		"A='value' trailing":         "invalid expression in env file at line 1, column 11: unexpected character 't' after value of A",
+		"export A":                   "invalid expression in env file at line 1, column 9: expected '=' after A",
+		"A@B=1":                      "invalid expression in env file at line 1, column 2: expected '=' after A",
+		"  export\tMY_KEY=`unclosed": "invalid expression in env file at line 1, column 17: unterminated quoted value",
+	}
+	for input, expected := range tests {
+		_, err := newEnvParser(input).parse()
+		if assert.Error(t, err, "expected %q to be invalid", input) {
+			assert.Equal(t, expected, err.Error())
+		}
+	}
+}
+
+func TestParseEnvVarDotenv(t *testing.T) {
+	name, value, err := newEnvParser("export NAME='value' # comment").parseExpression()
+	assert.Nil(t, err)
+	assert.Equal(t, "NAME", name)
+	assert.Equal(t, "value", value.Value)
+	assert.True(t, value.Literal)
+}
@@ -1,19 +1,85 @@
 package confik
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
-	"strconv"
+	"sort"
 	"strings"
 )
 
-// loadEnvFile will locate and load the environment file into a map[string]string
+// loadEnvFile will locate and load the environment file (or the layered environment files) into a map[string]envValue
 //
 // loadEnvFile will update the current environment with the files found in the environment file
-func loadEnvFile[T any](cfg Config[T]) (map[string]string, error) {
+// (unless [Config.EnvFileOverlay] or [Config.Sources] is set)
+func loadEnvFile[T any](cfg Config[T]) (map[string]envValue, error) {
+	var values map[string]envValue
+	var err error
+	if len(cfg.EnvFiles) > 0 {
+		values, err = readEnvFileLayers(cfg)
+	} else {
+		values, err = readDefaultEnvFile(cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// expand references to other variables (if applicable)
+	if cfg.ExpandVariables {
+		kv, err := expandEnvFile(values, cfg.sources(), cfg.EnvFileOverride, cfg.StrictExpansion)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range values {
+			v.Value = kv[k]
+			values[k] = v
+		}
+	}
+
+	// the variables are kept in memory when using an overlay
+	if cfg.overlay() {
+		return values, nil
+	}
+
+	// add the discovered environment variables in the environment file to the environment
+	for k, v := range values {
+		_, exists := os.LookupEnv(k)
+		if cfg.EnvFileOverride || !exists {
+			os.Setenv(k, v.Value)
+		}
+	}
+	return values, nil
+}
+
+// envFileSources will split the variables from the environment files into an [EnvFileSource] for each file so the
+// origin of a value names the file that set it.
+//
+// Each variable is only in the source of the last file that set it so the order of the sources does not matter.
+func envFileSources(values map[string]envValue) []Source {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var sources []Source
+	files := make(map[string]*EnvFileSource)
+	for _, key := range keys {
+		value := values[key]
+		source, exists := files[value.Path]
+		if !exists {
+			source = &EnvFileSource{MapSource: make(MapSource), Path: value.Path}
+			files[value.Path] = source
+			sources = append(sources, source)
+		}
+		source.MapSource[key] = value.Value
+	}
+	return sources
+}
+
+// readDefaultEnvFile will read the environment file at [Config.EnvFilePath] (or search for ".env").
+func readDefaultEnvFile[T any](cfg Config[T]) (map[string]envValue, error) {
 	var envPath string
 	if cfg.EnvFilePath == "" {
 		foundPath, err := findEnvFile()
@@ -27,10 +93,78 @@ func loadEnvFile[T any](cfg Config[T]) (map[string]string, error) {
 
 	// no .env found or provided - return empty map
 	if envPath == "" {
-		envMap := make(map[string]string)
+		envMap := make(map[string]envValue)
 		return envMap, nil
 	}
 
+	return readEnvFile(envPath)
+}
+
+// envProfile will get the profile from the config or the variable named by [Config.EnvProfileVar].
+func envProfile[T any](cfg Config[T]) (string, error) {
+	profile := cfg.EnvProfile
+	if profile == "" && cfg.EnvProfileVar != "" {
+		profile, _ = lookupSources(cfg.sources(), cfg.EnvProfileVar)
+	}
+	// the profile is used in a path so it must not escape the directory
+	if strings.ContainsAny(profile, `/\`) || strings.Contains(profile, "..") {
+		return "", fmt.Errorf("invalid environment profile: %s", profile)
+	}
+	return profile, nil
+}
+
+// readEnvFileLayers will read each of the environment files in [Config.EnvFiles] and merge them together.
+//
+// Files that do not exist are skipped and "{profile}" is replaced by the environment profile. Files containing
+// "{profile}" are skipped when there is no profile.
+func readEnvFileLayers[T any](cfg Config[T]) (map[string]envValue, error) {
+	profile, err := envProfile(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]envValue)
+	for _, envPath := range cfg.EnvFiles {
+		if strings.Contains(envPath, "{profile}") {
+			if profile == "" {
+				continue
+			}
+			envPath = strings.ReplaceAll(envPath, "{profile}", profile)
+		}
+
+		// skip the missing layers
+		if _, err := os.Stat(envPath); os.IsNotExist(err) {
+			continue
+		}
+
+		kv, err := readEnvFile(envPath)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range kv {
+			values[k] = v
+		}
+	}
+	return values, nil
+}
+
+// fileSuffix is the suffix of the variable holding the path to a file containing the value of another variable.
+const fileSuffix = "_FILE"
+
+// readValueFile will read the value of the environment variable envName from the file at path.
+//
+// A single trailing newline is removed from the contents of the file.
+func readValueFile(envName string, path string) (string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("%s=%s could not be read: %w", envName, path, err)
+	}
+	value := strings.TrimSuffix(string(contents), "\n")
+	return strings.TrimSuffix(value, "\r"), nil
+}
+
+// readEnvFile will open and parse the environment file at envPath into a map[string]envValue.
+func readEnvFile(envPath string) (map[string]envValue, error) {
 	// check if the .env file exists
 	stat, err := os.Stat(envPath)
 	if err != nil {
@@ -52,19 +186,15 @@ func loadEnvFile[T any](cfg Config[T]) (map[string]string, error) {
 	}
 	defer file.Close()
 
-	kv, err := parseEnvFile(file)
+	values, err := parseEnvValues(file)
 	if err != nil {
 		return nil, err
 	}
-
-	// add the discovered environment variables in the environment file to the environment
-	for k, v := range kv {
-		_, exists := os.LookupEnv(k)
-		if cfg.EnvFileOverride || !exists {
-			os.Setenv(k, v)
-		}
+	for k, v := range values {
+		v.Path = envPath
+		values[k] = v
 	}
-	return kv, nil
+	return values, nil
 }
 
 // findEnvFile will locate the .env file by looking in the current directory and recursing up the directory structure
@@ -95,55 +225,38 @@ func findEnvFile() (string, error) {
 	}
 }
 
-// parseEnvVar will parse an environment variable in the format NAME=VALUE.
-func parseEnvVar(expression string) (string, string, error) {
-	// ensure format of the expression is correct
-	if !strings.Contains(expression, "=") {
-		return "", "", fmt.Errorf("invalid expression in env file: %s", expression)
-	}
-
-	// split the variable NAME=value [NAME, value]
-	parts := strings.SplitN(expression, "=", 2)
-	variable := strings.Trim(parts[0], " ")
-
-	// remove any quotes
-	unquoted, err := strconv.Unquote(parts[1])
-	if err != nil {
-		return variable, parts[1], nil
-	}
-	return variable, unquoted, nil
-}
-
-// parseEnvFile will convert an environment file into a map[string]string
+// parseEnvValues will convert an environment file into a map[string]envValue
 //
 // Expects the file in the format:
 //
 //	MY_VARIABLE=MY_NAME
-//	OTHER_VARIABLE="QUOTED_VALUE"
+//	export OTHER_VARIABLE="QUOTED_VALUE" # comment
+//	LITERAL_VARIABLE='C:\new\path'
+//	MULTILINE_VARIABLE="LINE 1
+//	LINE 2"
 //
 // Notes:
-//   - Quoted values will be unquoted
+//   - Variables can be prefixed by "export"
+//   - Double quoted values support the escapes \n, \r, \t, \", \\ and can span multiple lines
+//   - Single quoted and backtick quoted values are literal and can span multiple lines
 //   - Blank lines will be ingored
 //   - Comments (starting with // or #) will be ignored
-//   - Whitespace around variables and their values will be stripped
-func parseEnvFile(reader io.Reader) (map[string]string, error) {
-	scanner := bufio.NewScanner(reader)
-	// map to store all the key => value pairs we find in the ".env" file
-	kv := make(map[string]string)
-	for scanner.Scan() {
-		expression := scanner.Text()
-		// ignore comments and blank lines
-		if strings.HasPrefix(expression, "#") || strings.HasPrefix(expression, "//") || strings.TrimSpace(expression) == "" {
-			continue
-		}
-
-		key, value, err := parseEnvVar(expression)
-		if err != nil {
-			return nil, err
-		}
+//   - Inline comments (starting with #) after a value will be ignored (unquoted values require whitespace before the #)
+//   - Whitespace around variables and their unquoted values will be stripped
+//   - Errors will include the line and column of the invalid expression
+func parseEnvValues(reader io.Reader) (map[string]envValue, error) {
+	input, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return newEnvParser(string(input)).parse()
+}
 
-		// update the store
-		kv[strings.TrimSpace(key)] = strings.TrimSpace(value)
+// envValuesToMap will convert a map[string]envValue to a map[string]string (without expanding the values).
+func envValuesToMap(kv map[string]envValue) map[string]string {
+	values := make(map[string]string, len(kv))
+	for k, v := range kv {
+		values[k] = v.Value
 	}
-	return kv, nil
+	return values
 }
@@ -10,10 +10,10 @@ import (
 )
 
 func TestParseEnvVar(t *testing.T) {
-	name, value, err := parseEnvVar("VARIABLE=")
+	name, value, err := newEnvParser("VARIABLE=").parseExpression()
 	assert.Nil(t, err)
 	assert.Equal(t, "VARIABLE", name)
-	assert.Equal(t, "", value)
+	assert.Equal(t, "", value.Value)
 }
 
 func TestFindEnvFile(t *testing.T) {
@@ -48,7 +48,7 @@ func TestLoadEnvFileNoEnvFile(t *testing.T) {
 
 	kv, err := loadEnvFile(Config[testAllTypes]{})
 	assert.Nil(t, err)
-	assert.Equal(t, map[string]string{}, kv)
+	assert.Equal(t, map[string]string{}, envValuesToMap(kv))
 }
 
 func TestLoadEnvFileDoesNotExist(t *testing.T) {
@@ -65,7 +65,64 @@ func TestLoadEnvFileInvalid(t *testing.T) {
 		EnvFilePath: "testdata/.invalid",
 	})
 	if assert.Error(t, err) {
-		assert.Equal(t, "invalid expression in env file: INVALID", err.Error())
+		assert.Equal(t, "invalid expression in env file at line 1, column 8: expected '=' after INVALID", err.Error())
+	}
+}
+
+func testLayers(profile string, profileVar string) Config[testAllTypes] {
+	files := make([]string, len(DefaultEnvFiles))
+	for i, file := range DefaultEnvFiles {
+		files[i] = filepath.Join("testdata/layers", file)
+	}
+	return Config[testAllTypes]{
+		EnvFiles:       files,
+		EnvProfile:     profile,
+		EnvProfileVar:  profileVar,
+		EnvFileOverlay: true,
+	}
+}
+
+func TestLoadEnvFileLayers(t *testing.T) {
+	os.Clearenv()
+	kv, err := loadEnvFile(testLayers("", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"NAME": "base", "LEVEL": "local", "REGION": "base", "PORT": "1"}, envValuesToMap(kv))
+
+	kv, err = loadEnvFile(testLayers("dev", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"NAME": "base", "LEVEL": "local", "REGION": "dev", "PORT": "3"}, envValuesToMap(kv))
+
+	// missing layers are skipped
+	kv, err = loadEnvFile(testLayers("prod", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, "base", kv["REGION"].Value)
+}
+
+func TestLoadEnvFileLayersProfileVar(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("APP_ENV", "dev")
+	kv, err := loadEnvFile(testLayers("", "APP_ENV"))
+	assert.Nil(t, err)
+	assert.Equal(t, "dev", kv["REGION"].Value)
+
+	// the profile in the config takes precedence
+	kv, err = loadEnvFile(testLayers("prod", "APP_ENV"))
+	assert.Nil(t, err)
+	assert.Equal(t, "base", kv["REGION"].Value)
+
+	os.Setenv("APP_ENV", "../dev")
+	_, err = loadEnvFile(testLayers("", "APP_ENV"))
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid environment profile: ../dev", err.Error())
+	}
+}
+
+func TestLoadEnvFileLayersIsDir(t *testing.T) {
+	_, err := loadEnvFile(Config[testAllTypes]{
+		EnvFiles: []string{"testdata/layers/.env", "testdata/"},
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "environment file is a directory: testdata/", err.Error())
 	}
 }
 
@@ -80,8 +137,9 @@ TC_LONG_NAME_1=test
 
 
 `
-	kv, err := parseEnvFile(strings.NewReader(input))
+	values, err := newEnvParser(input).parse()
 	assert.Nil(t, err)
+	kv := envValuesToMap(values)
 	expected := map[string]string{
 		"TC_UNQUOTED":    "1",
 		"TC_QUOTED":      "hello world",
@@ -98,9 +156,9 @@ TC_LONG_NAME_1=test
 
 func TestParseEnvFileInvalid(t *testing.T) {
 	input := "BLAH"
-	_, err := parseEnvFile(strings.NewReader(input))
+	_, err := newEnvParser(input).parse()
 	if assert.Error(t, err) {
-		assert.Equal(t, "invalid expression in env file: BLAH", err.Error())
+		assert.Equal(t, "invalid expression in env file at line 1, column 5: expected '=' after BLAH", err.Error())
 	}
 }
 
@@ -115,9 +173,8 @@ TC_LONG_NAME_1=test
 
 
 `
-	reader := strings.NewReader(input)
 	for n := 0; n < b.N; n++ {
-		_, err := parseEnvFile(reader)
+		_, err := parseEnvValues(strings.NewReader(input))
 		if err != nil {
 			panic(err)
 		}
@@ -0,0 +1,74 @@
+package confik
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrMissing    = errors.New("missing environment variable")      // the environment variable does not exist and has no default
+	ErrInvalidTag = errors.New("invalid tag")                       // the tag on the field is invalid (or refers to an unknown validator)
+	ErrValidation = errors.New("validation failed")                 // the value failed validation
+	ErrParse      = errors.New("failed to parse environment value") // the value could not be read, expanded or converted to the type of the field
+)
+
+// FieldError is an error that occurred while loading a single field.
+//
+// The Kind will be one of [ErrMissing], [ErrInvalidTag], [ErrValidation] or [ErrParse] and can be checked with [errors.Is].
+type FieldError struct {
+	Field   string // dotted path to the field within the struct
+	EnvName string // name of the environment variable
+	Value   string // raw value of the environment variable (if any)
+	Kind    error  // the kind of failure
+	Err     error  // the underlying error
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap will return the kind of failure and the underlying error.
+func (e *FieldError) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+// LoadError is the error returned when one or more fields could not be loaded.
+//
+// All the fields are loaded before the error is returned so every problem is reported at once.
+type LoadError struct {
+	Errors []*FieldError // errors for each field that failed to load
+}
+
+func (e *LoadError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d errors occurred while loading the environment:", len(e.Errors)))
+	for _, err := range e.Errors {
+		sb.WriteString("\n  - ")
+		sb.WriteString(err.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap will return the errors for each field.
+func (e *LoadError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// add will append a new [FieldError] to the list of errors.
+func (e *LoadError) add(field string, envName string, value string, kind error, err error) {
+	e.Errors = append(e.Errors, &FieldError{
+		Field:   field,
+		EnvName: envName,
+		Value:   value,
+		Kind:    kind,
+		Err:     err,
+	})
+}
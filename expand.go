@@ -0,0 +1,208 @@
+package confik
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// lookupFunc is the type of function used to resolve the value of a variable during expansion.
+type lookupFunc = func(name string) (string, bool, error)
+
+// isVarStart will check if c can start the name of a variable in a reference.
+func isVarStart(c byte) bool {
+	return c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+// isVarChar will check if c can be used in the name of a variable in a reference.
+func isVarChar(c byte) bool {
+	return isVarStart(c) || (c >= '0' && c <= '9')
+}
+
+// isVarName will check if name is a valid name for a variable in a reference.
+func isVarName(name string) bool {
+	if name == "" || !isVarStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isVarChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// findClosingBrace will find the index of the brace closing the reference starting at start (or -1).
+func findClosingBrace(value string, start int) int {
+	depth := 0
+	for i := start; i < len(value); i++ {
+		switch value[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// expandVariables will replace references to variables in value with the result of lookup.
+//
+// Supported references:
+//
+//	$NAME
+//	${NAME}
+//	${NAME:-fallback} (the fallback is used if NAME does not exist or is empty)
+//
+// A reference can be escaped with a backslash (\$NAME). References to variables that do not exist are replaced
+// with an empty string unless strict is set.
+func expandVariables(value string, lookup lookupFunc, strict bool) (string, error) {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		// handle escaped references
+		if c == '\\' && i+1 < len(value) && value[i+1] == '$' {
+			sb.WriteByte('$')
+			i++
+			continue
+		}
+		if c != '$' {
+			sb.WriteByte(c)
+			continue
+		}
+
+		var name, fallback string
+		var hasFallback bool
+		if i+1 < len(value) && value[i+1] == '{' {
+			// ${NAME} or ${NAME:-fallback}
+			end := findClosingBrace(value, i+1)
+			if end == -1 {
+				return "", fmt.Errorf("unterminated reference in %s", value)
+			}
+			name, fallback, hasFallback = strings.Cut(value[i+2:end], ":-")
+			if !isVarName(name) {
+				return "", fmt.Errorf("invalid reference %s in %s", value[i:end+1], value)
+			}
+			i = end
+		} else {
+			// $NAME
+			end := i + 1
+			if end < len(value) && isVarStart(value[end]) {
+				for end < len(value) && isVarChar(value[end]) {
+					end++
+				}
+			}
+			// a lone $ is kept as is
+			if end == i+1 {
+				sb.WriteByte('$')
+				continue
+			}
+			name = value[i+1 : end]
+			i = end - 1
+		}
+
+		resolved, exists, err := lookup(name)
+		if err != nil {
+			return "", err
+		}
+		if hasFallback && (!exists || resolved == "") {
+			if resolved, err = expandVariables(fallback, lookup, strict); err != nil {
+				return "", err
+			}
+		} else if !exists && strict {
+			return "", fmt.Errorf("reference to undefined variable %s", name)
+		}
+		sb.WriteString(resolved)
+	}
+	return sb.String(), nil
+}
+
+// envFileExpander will expand the values of an environment file.
+//
+// References are resolved with the same precedence used when loading the environment file. References to
+// other variables in the environment file are expanded first and circular references are reported as an error.
+type envFileExpander struct {
+	values   map[string]envValue // the values in the environment file
+	sources  []Source            // the other sources to resolve references from
+	override bool                // do the values in the environment file take precedence over the sources?
+	strict   bool                // return an error for references to undefined variables?
+	expanded map[string]string   // values that have already been expanded
+	visiting []string            // variables currently being expanded (to detect cycles)
+}
+
+// expandEnvFile will expand references to variables in the values of an environment file.
+func expandEnvFile(values map[string]envValue, sources []Source, override bool, strict bool) (map[string]string, error) {
+	e := &envFileExpander{
+		values:   values,
+		sources:  sources,
+		override: override,
+		strict:   strict,
+		expanded: make(map[string]string, len(values)),
+	}
+	// expand in a consistent order so the errors are deterministic
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, err := e.expand(key); err != nil {
+			return nil, fmt.Errorf("failed to expand %s in env file: %w", key, err)
+		}
+	}
+	return e.expanded, nil
+}
+
+// lookup will resolve the value of a variable referenced by a value in the environment file.
+func (e *envFileExpander) lookup(name string) (string, bool, error) {
+	_, inFile := e.values[name]
+	if !e.override || !inFile {
+		if value, exists := lookupSources(e.sources, name); exists {
+			return value, true, nil
+		}
+	}
+	if !inFile {
+		return "", false, nil
+	}
+	value, err := e.expand(name)
+	return value, true, err
+}
+
+// expand will expand the value of the variable named key in the environment file.
+func (e *envFileExpander) expand(key string) (string, error) {
+	if value, exists := e.expanded[key]; exists {
+		return value, nil
+	}
+	for i, visiting := range e.visiting {
+		if visiting == key {
+			cycle := append(e.visiting[i:len(e.visiting):len(e.visiting)], key)
+			return "", fmt.Errorf("circular reference: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	value := e.values[key]
+	if value.Literal {
+		e.expanded[key] = value.Value
+		return value.Value, nil
+	}
+
+	e.visiting = append(e.visiting, key)
+	expanded, err := expandVariables(value.Value, e.lookup, e.strict)
+	e.visiting = e.visiting[:len(e.visiting)-1]
+	if err != nil {
+		return "", err
+	}
+	e.expanded[key] = expanded
+	return expanded, nil
+}
+
+// sourceLookup will create a [lookupFunc] that resolves variables from the sources.
+func sourceLookup(sources []Source) lookupFunc {
+	return func(name string) (string, bool, error) {
+		value, exists := lookupSources(sources, name)
+		return value, exists, nil
+	}
+}
@@ -0,0 +1,167 @@
+package confik
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandVariables(t *testing.T) {
+	lookup := sourceLookup([]Source{MapSource{"USER": "bob", "HOST": "localhost", "EMPTY": ""}})
+	tests := map[string]string{
+		"plain":                        "plain",
+		"$USER@$HOST":                  "bob@localhost",
+		"${USER}_suffix":               "bob_suffix",
+		"$USER_suffix":                 "",
+		"${MISSING:-fallback}":         "fallback",
+		"${EMPTY:-fallback}":           "fallback",
+		"${USER:-fallback}":            "bob",
+		"${MISSING:-${USER}@${HOST}}":  "bob@localhost",
+		"${MISSING:-}":                 "",
+		"\\$USER":                      "$USER",
+		"cost: $5 or $":                "cost: $5 or $",
+		"$MISSING":                     "",
+		"postgres://${USER}@${HOST}/d": "postgres://bob@localhost/d",
+	}
+	for input, expected := range tests {
+		result, err := expandVariables(input, lookup, false)
+		assert.Nil(t, err, "expected %s to expand", input)
+		assert.Equal(t, expected, result, "invalid expansion of %s", input)
+	}
+}
+
+func TestExpandVariablesErrors(t *testing.T) {
+	lookup := sourceLookup([]Source{MapSource{}})
+	tests := map[string]string{
+		"${USER":       "unterminated reference in ${USER",
+		"${1USER}":     "invalid reference ${1USER} in ${1USER}",
+		"${}":          "invalid reference ${} in ${}",
+		"$MISSING":     "reference to undefined variable MISSING",
+		"${A:-$B}":     "reference to undefined variable B",
+		"${MISSING}ok": "reference to undefined variable MISSING",
+	}
+	for input, expected := range tests {
+		_, err := expandVariables(input, lookup, true)
+		if assert.Error(t, err, "expected %s to be invalid", input) {
+			assert.Equal(t, expected, err.Error())
+		}
+	}
+}
+
+func TestExpandEnvFile(t *testing.T) {
+	values := map[string]envValue{
+		"A":       {Value: "${B}-${C}"},
+		"B":       {Value: "b"},
+		"C":       {Value: "$B$B"},
+		"LITERAL": {Value: "$B", Literal: true},
+		"ENV":     {Value: "file"},
+		"FROM":    {Value: "$ENV"},
+	}
+	sources := []Source{MapSource{"ENV": "env"}}
+	kv, err := expandEnvFile(values, sources, false, true)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"A":       "b-bb",
+		"B":       "b",
+		"C":       "bb",
+		"LITERAL": "$B",
+		"ENV":     "file",
+		"FROM":    "env",
+	}, kv)
+
+	kv, err = expandEnvFile(values, sources, true, true)
+	assert.Nil(t, err)
+	assert.Equal(t, "file", kv["FROM"])
+}
+
+func TestExpandEnvFileErrors(t *testing.T) {
+	values := map[string]envValue{
+		"A": {Value: "${B}"},
+		"B": {Value: "${C:-$A}"},
+	}
+	_, err := expandEnvFile(values, nil, false, false)
+	if assert.Error(t, err) {
+		assert.Equal(t, "failed to expand A in env file: circular reference: A -> B -> A", err.Error())
+	}
+
+	values = map[string]envValue{
+		"A": {Value: "${MISSING}"},
+	}
+	_, err = expandEnvFile(values, nil, false, true)
+	if assert.Error(t, err) {
+		assert.Equal(t, "failed to expand A in env file: reference to undefined variable MISSING", err.Error())
+	}
+}
+
+type testExpand struct {
+	DatabaseUrl string
+	Literal     string
+	Escaped     string
+	Default     string `env:"DEFAULT,default=${DB_USER}@${DB_PORT:-5432}"`
+}
+
+func TestLoadFromEnvExpand(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DB_NAME", "app")
+	cfg, err := LoadFromEnv(Config[testExpand]{
+		UseEnvFile:      true,
+		EnvFilePath:     "testdata/.expand",
+		ExpandVariables: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "postgres://admin@db.internal/app", cfg.DatabaseUrl)
+	assert.Equal(t, "${DB_USER}", cfg.Literal)
+	assert.Equal(t, "${DB_USER}", cfg.Escaped)
+	assert.Equal(t, "admin@5432", cfg.Default)
+	value, _ := os.LookupEnv("DATABASE_URL")
+	assert.Equal(t, "postgres://admin@db.internal/app", value)
+}
+
+func TestLoadFromEnvExpandOverlay(t *testing.T) {
+	t.Parallel()
+	cfg, err := LoadFromEnv(Config[testExpand]{
+		UseEnvFile:      true,
+		EnvFilePath:     "testdata/.expand",
+		EnvFileOverlay:  true,
+		ExpandVariables: true,
+		Sources:         []Source{MapSource{"DB_NAME": "app", "DB_USER": "root"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "postgres://root@db.internal/app", cfg.DatabaseUrl)
+	assert.Equal(t, "root@5432", cfg.Default)
+}
+
+func TestLoadFromEnvExpandStrict(t *testing.T) {
+	os.Clearenv()
+	_, err := LoadFromEnv(Config[testExpand]{
+		UseEnvFile:      true,
+		EnvFilePath:     "testdata/.expand",
+		ExpandVariables: true,
+		StrictExpansion: true,
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "failed to expand DATABASE_URL in env file: reference to undefined variable DB_NAME", err.Error())
+	}
+
+	os.Clearenv()
+	_, err = LoadFromEnv(Config[testDefaultValue]{
+		UseEnvFile:      false,
+		ExpandVariables: true,
+		StrictExpansion: true,
+	})
+	assert.Nil(t, err)
+
+	type testStrictDefault struct {
+		Website string `env:"WEBSITE,default=$HOST"`
+	}
+	_, err = LoadFromEnv(Config[testStrictDefault]{
+		UseEnvFile:      false,
+		ExpandVariables: true,
+		StrictExpansion: true,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrParse)
+		assert.Equal(t, "failed to expand default value of WEBSITE: reference to undefined variable HOST", err.Error())
+	}
+}
@@ -9,8 +9,9 @@ import (
 
 // FieldConfig is the representation of the configuration for a field within a struct (after tags have been parsed).
 type FieldConfig struct {
-	ConfigTag            // the configuration specified in the tag
-	Validate  *Validator // the custom validator for this field
+	ConfigTag             // the configuration specified in the tag
+	Validate  []Validator // the validators for this field (run in order)
+	Field     string      // the dotted path to the field within the struct
 }
 
 // merge two maps together into a new map.
@@ -26,13 +27,17 @@ func mergeMap[T comparable, V any](a map[T]V, b map[T]V) map[T]V {
 }
 
 // newFieldConfig will create a new FieldConfig for the given [reflect.StructField].
-func newFieldConfig[T any](cfg Config[T], rv reflect.StructField) (*FieldConfig, error) {
+//
+// The prefix is prepended to the environment variable name and the path is the dotted path to the field (used for nested structs).
+// The validators for the field are compiled from the expressions in the tag using validators (the names of
+// valueValidators are skipped as they are compiled with the type of the field).
+func newFieldConfig(validators map[string]Validator, valueValidators map[string]ValueValidator, rv reflect.StructField, prefix string, path string) (*FieldConfig, error) {
 	var fieldConfig FieldConfig
 	tagStr := rv.Tag.Get("env")
 	if tagStr != "" {
 		tag, err := parseEnvTag(tagStr)
 		if err != nil {
-			return nil, fmt.Errorf("invalid tag on field %s: %w", rv.Name, err)
+			return nil, fmt.Errorf("invalid tag on field %s: %w", path, err)
 		}
 		fieldConfig.ConfigTag = *tag
 	} else {
@@ -40,20 +45,59 @@ func newFieldConfig[T any](cfg Config[T], rv reflect.StructField) (*FieldConfig,
 			Name: toEnvName(rv.Name),
 		}
 	}
+	fieldConfig.Name = prefix + fieldConfig.Name
+	fieldConfig.Field = path
 
-	validators := mergeMap(fieldValidators, cfg.Validators)
-
-	validatorName := fieldConfig.Validator
-	if validatorName != nil {
-		validator, exists := validators[*validatorName]
-		if !exists {
-			return nil, fmt.Errorf("unknown validator: %s", *validatorName)
+	for _, expr := range fieldConfig.Validators {
+		if _, exists := valueValidators[expr]; exists {
+			continue
+		}
+		validator, err := compileValidator(validators, expr)
+		if err != nil {
+			return nil, err
 		}
-		fieldConfig.Validate = &validator
+		fieldConfig.Validate = append(fieldConfig.Validate, validator)
 	}
 	return &fieldConfig, nil
 }
 
+// isConditional will check if the field is only required depending on other fields (like required_if).
+func (fc *FieldConfig) isConditional() bool {
+	return fc.RequiredIf != nil || fc.RequiredWith != nil || fc.OneOfGroup != nil
+}
+
+// isLoadable will check if the field can be loaded.
+//
+// Unexported fields are skipped (the exported fields of embedded structs can still be set).
+func isLoadable(field reflect.StructField) bool {
+	return field.IsExported() || (field.Anonymous && field.Type.Kind() == reflect.Struct)
+}
+
+// joinFieldPath will append the name of a field to the dotted path of its parent.
+func joinFieldPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	if name == "" {
+		return path
+	}
+	return path + "." + name
+}
+
+// childPrefix will return the prefix for the fields of a nested struct.
+//
+// The parent prefix is followed by the prefix setting (if specified) or the name of the field. Embedded structs
+// without a tag share the prefix of their parent.
+func (fc *FieldConfig) childPrefix(field reflect.StructField, parentPrefix string) string {
+	if _, tagged := field.Tag.Lookup("env"); field.Anonymous && !tagged {
+		return parentPrefix
+	}
+	if fc.Prefix != nil {
+		return parentPrefix + *fc.Prefix
+	}
+	return fc.Name + "_"
+}
+
 // verifyEnvName will ensure that a variable is in a suitable format for an environment variable.
 func verifyEnvName(name string) error {
 	if len(name) == 0 {
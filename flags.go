@@ -0,0 +1,150 @@
+package confik
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// flagValue is a [flag.Value] that records the raw value of a flag registered by [BindFlags].
+type flagValue struct {
+	envName string // the name of the variable for the flag
+	value   string // the raw value of the flag
+	isBool  bool   // can the flag be set without a value (like --debug)?
+	set     bool   // was the flag set on the command line?
+}
+
+// String will return the raw value of the flag.
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.value
+}
+
+// Set will record the raw value of the flag (it is parsed and validated when loaded).
+func (v *flagValue) Set(value string) error {
+	v.value = value
+	v.set = true
+	return nil
+}
+
+// IsBoolFlag will check if the flag can be set without a value.
+func (v *flagValue) IsBoolFlag() bool {
+	return v.isBool
+}
+
+// flagName will convert the name of a variable into the name of a flag (DATABASE_URL is database-url).
+func flagName(envName string) string {
+	return strings.ReplaceAll(strings.ToLower(envName), "_", "-")
+}
+
+// FlagSource is a [Source] backed by the flags registered by [BindFlags] that were set on the command line.
+type FlagSource struct {
+	FlagSet *flag.FlagSet // the flags to read values from
+}
+
+// lookupFlag will get the value registered by [BindFlags] for the variable named by key.
+func (s *FlagSource) lookupFlag(key string) (*flagValue, bool) {
+	f := s.FlagSet.Lookup(flagName(key))
+	if f == nil {
+		return nil, false
+	}
+	value, ok := f.Value.(*flagValue)
+	if !ok || value.envName != key {
+		return nil, false
+	}
+	return value, true
+}
+
+// Lookup will get the value of the flag for the variable named by key (if it was set).
+func (s *FlagSource) Lookup(key string) (string, bool) {
+	value, ok := s.lookupFlag(key)
+	if !ok || !value.set {
+		return "", false
+	}
+	return value.value, true
+}
+
+// Keys will list the names of the variables for the flags that were set.
+func (s *FlagSource) Keys() []string {
+	var keys []string
+	s.FlagSet.Visit(func(f *flag.Flag) {
+		if value, ok := f.Value.(*flagValue); ok {
+			keys = append(keys, value.envName)
+		}
+	})
+	sort.Strings(keys)
+	return keys
+}
+
+// String will describe the source.
+func (s *FlagSource) String() string {
+	return "flags"
+}
+
+// BindFlags will register a flag on fs for each field of T and return a copy of cfg that reads from them.
+//
+// The name of each flag is derived from the name of the variable (DATABASE_URL is --database-url), the default
+// comes from the default setting and the help text from the desc setting. Flags that are set take precedence over
+// the environment, the environment file and the defaults:
+//
+//	cfg, err := BindFlags(flag.CommandLine, DefaultConfig[MyStruct]())
+//	...
+//	flag.Parse()
+//	value, err := LoadFromEnv(cfg)
+//
+// Slices and maps of nested structs are not registered as the names of their variables are not known.
+func BindFlags[T any](fs *flag.FlagSet, cfg Config[T]) (Config[T], error) {
+	loader, err := NewLoader(cfg)
+	if err != nil {
+		return cfg, err
+	}
+	if err := bindFlags(fs, loader.plan); err != nil {
+		return cfg, err
+	}
+	cfg.Sources = append([]Source{&FlagSource{FlagSet: fs}}, cfg.sources()...)
+	return cfg, nil
+}
+
+// bindFlags will register a flag on fs for each field in the plan.
+func bindFlags(fs *flag.FlagSet, plan *structPlan) error {
+	for _, fp := range plan.fields {
+		switch {
+		case fp.nested == nil:
+		case fp.typ.Kind() == reflect.Struct || fp.typ.Kind() == reflect.Pointer:
+			if err := bindFlags(fs, fp.nested); err != nil {
+				return err
+			}
+			continue
+		default:
+			continue
+		}
+
+		// fields that share a variable share the flag
+		name := flagName(fp.Name)
+		if f := fs.Lookup(name); f != nil {
+			if value, ok := f.Value.(*flagValue); ok && value.envName == fp.Name {
+				continue
+			}
+			return fmt.Errorf("flag %s for field %s is already defined", name, fp.Field)
+		}
+		value := &flagValue{
+			envName: fp.Name,
+			isBool:  fp.typ.Kind() == reflect.Bool || (fp.typ.Kind() == reflect.Pointer && fp.typ.Elem().Kind() == reflect.Bool),
+		}
+		if fp.Default != nil {
+			value.value = *fp.Default
+		}
+		usage := fp.Description
+		if usage == "" {
+			usage = fmt.Sprintf("set %s", fp.Name)
+		} else {
+			usage = fmt.Sprintf("%s (%s)", usage, fp.Name)
+		}
+		fs.Var(value, name, usage)
+	}
+	return nil
+}
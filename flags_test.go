@@ -0,0 +1,102 @@
+package confik
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testFlags struct {
+	DatabaseURL string `env:"DATABASE_URL,desc=the database connection string"`
+	Port        uint16 `env:"PORT,default=8080"`
+	Debug       bool   `env:"DEBUG,optional"`
+	Name        string `env:"NAME,default=app"`
+	Cache       struct {
+		Size int `env:"SIZE,default=10"`
+	}
+	Upstreams []struct {
+		Host string
+	} `env:"UPSTREAMS,optional"`
+}
+
+func TestFlagName(t *testing.T) {
+	assert.Equal(t, "database-url", flagName("DATABASE_URL"))
+	assert.Equal(t, "port", flagName("PORT"))
+}
+
+func TestBindFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err := BindFlags(fs, Config[testFlags]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+
+	f := fs.Lookup("database-url")
+	if assert.NotNil(t, f) {
+		assert.Equal(t, "the database connection string (DATABASE_URL)", f.Usage)
+	}
+	f = fs.Lookup("port")
+	if assert.NotNil(t, f) {
+		assert.Equal(t, "8080", f.DefValue)
+		assert.Equal(t, "set PORT", f.Usage)
+	}
+	assert.NotNil(t, fs.Lookup("cache-size"))
+	assert.Nil(t, fs.Lookup("upstreams"))
+
+	// precedence is flag > env > default
+	os.Clearenv()
+	os.Setenv("DATABASE_URL", "postgres://env")
+	os.Setenv("PORT", "9090")
+	os.Setenv("NAME", "env")
+	err = fs.Parse([]string{"--database-url", "postgres://flag", "--debug", "--cache-size=20"})
+	assert.Nil(t, err)
+
+	loader, err := NewLoader(cfg)
+	assert.Nil(t, err)
+	value, origins, err := loader.LoadWithOrigins()
+	assert.Nil(t, err)
+	assert.Equal(t, "postgres://flag", value.DatabaseURL)
+	assert.Equal(t, uint16(9090), value.Port)
+	assert.True(t, value.Debug)
+	assert.Equal(t, "env", value.Name)
+	assert.Equal(t, 20, value.Cache.Size)
+	assert.Contains(t, origins, Origin{Field: "DatabaseURL", EnvName: "DATABASE_URL", Source: "flags"})
+	assert.Equal(t, []string{"CACHE_SIZE", "DATABASE_URL", "DEBUG"}, cfg.Sources[0].Keys())
+}
+
+func TestBindFlagsEnvFileOverride(t *testing.T) {
+	os.Clearenv()
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	cfg, err := BindFlags(fs, Config[testCustomValidator]{
+		UseEnvFile:      true,
+		EnvFilePath:     "testdata/.uri",
+		EnvFileOverlay:  true,
+		EnvFileOverride: true,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, fs.Parse([]string{"--website", "https://flag.com"}))
+	value, err := LoadFromEnv(cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://flag.com", value.Website)
+}
+
+func TestBindFlagsErrors(t *testing.T) {
+	var output bytes.Buffer
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(&output)
+	fs.String("port", "", "")
+	_, err := BindFlags(fs, Config[testFlags]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "flag port for field Port is already defined", err.Error())
+	}
+
+	_, err = BindFlags(flag.NewFlagSet("test", flag.ContinueOnError), Config[testLoaderInvalidTags]{})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrInvalidTag)
+	}
+}
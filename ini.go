@@ -0,0 +1,195 @@
+package confik
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// INIFileSource is a [Source] backed by a parsed INI file.
+//
+// The keys in a section are prefixed by the name of the section so [database] followed by host=x is DATABASE_HOST.
+// The file is read once when the source is created.
+type INIFileSource struct {
+	MapSource        // the values parsed from the file
+	Path      string // path to the INI file
+}
+
+// NewINIFileSource will create a new [INIFileSource] by parsing the INI file at path.
+func NewINIFileSource(path string) (*INIFileSource, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("ini file does not exist: %s", path)
+		}
+		return nil, err
+	}
+	if stat.IsDir() {
+		return nil, fmt.Errorf("ini file is a directory: %s", path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	kv, err := parseINIFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return &INIFileSource{
+		MapSource: kv,
+		Path:      path,
+	}, nil
+}
+
+// String will describe the source.
+func (s *INIFileSource) String() string {
+	return "ini file " + s.Path
+}
+
+// iniName will convert the name of a section or key into the name of a variable.
+//
+// Letters are uppercased and separators (like "." and "-") are replaced with "_".
+func iniName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', '-', ' ':
+			return '_'
+		}
+		return r
+	}, strings.ToUpper(name))
+}
+
+// parseINIFile will parse an INI file into a map of variables.
+//
+// Supported syntax:
+//
+//	; comment
+//	# comment
+//	top=value             // TOP (keys before the first section have no prefix)
+//	[database]
+//	host = localhost      // DATABASE_HOST
+//	name: app             // DATABASE_NAME
+//	user = "root"         // quoted values can contain escapes (\n, \t, \" and \\)
+//	pass = 'p@ss;word'    // single quoted values are literal
+//	port = 5432 ; comment // inline comments must be preceded by whitespace
+//	[database.replica]
+//	host = replica        // DATABASE_REPLICA_HOST
+func parseINIFile(reader io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	lines := make(map[string]int)
+	prefix := ""
+
+	scanner := bufio.NewScanner(reader)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := scanner.Text()
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		line = strings.TrimSpace(line)
+
+		// skip empty lines and comments
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		// start a new section
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end == -1 {
+				return nil, iniError(lineNum, "unterminated section header %s", line)
+			}
+			if rest := strings.TrimSpace(line[end+1:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+				return nil, iniError(lineNum, "unexpected characters after section header %s", line[:end+1])
+			}
+			section := iniName(strings.TrimSpace(line[1:end]))
+			if err := verifyEnvName(section); err != nil {
+				return nil, iniError(lineNum, "invalid section %s", line[:end+1])
+			}
+			prefix = section + "_"
+			continue
+		}
+
+		// split the key from the value
+		sep := strings.IndexAny(line, "=:")
+		if sep == -1 {
+			return nil, iniError(lineNum, "expected '=' after %s", line)
+		}
+		key := strings.TrimSpace(line[:sep])
+		if key == "" {
+			return nil, iniError(lineNum, "expected key before %q", line[sep])
+		}
+		name := prefix + iniName(key)
+		if err := verifyEnvName(name); err != nil {
+			return nil, iniError(lineNum, "invalid key %s", key)
+		}
+		value, err := parseINIValue(strings.TrimSpace(line[sep+1:]))
+		if err != nil {
+			return nil, iniError(lineNum, "%s", err)
+		}
+		if other, exists := lines[name]; exists {
+			return nil, iniError(lineNum, "duplicate key %s (first defined at line %d)", name, other)
+		}
+		lines[name] = lineNum
+		values[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// parseINIValue will parse the (trimmed) value of a key in an INI file.
+func parseINIValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	// unquoted values end at an inline comment
+	quote := value[0]
+	if quote != '"' && quote != '\'' {
+		for i := 1; i < len(value); i++ {
+			if (value[i] == ';' || value[i] == '#') && (value[i-1] == ' ' || value[i-1] == '\t') {
+				return strings.TrimSpace(value[:i]), nil
+			}
+		}
+		return value, nil
+	}
+
+	var sb strings.Builder
+	for i := 1; i < len(value); i++ {
+		c := value[i]
+		if c == quote {
+			if rest := strings.TrimSpace(value[i+1:]); rest != "" && rest[0] != ';' && rest[0] != '#' {
+				return "", fmt.Errorf("unexpected characters after quoted value %s", value[:i+1])
+			}
+			return sb.String(), nil
+		}
+		if c == '\\' && quote == '"' && i+1 < len(value) {
+			i++
+			switch value[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case '"', '\\':
+				sb.WriteByte(value[i])
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(value[i])
+			}
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return "", fmt.Errorf("unterminated quoted value %s", value)
+}
+
+// iniError will create an error for an invalid expression at the given line.
+func iniError(line int, format string, args ...any) error {
+	return fmt.Errorf("invalid expression in ini file at line %d: %s", line, fmt.Sprintf(format, args...))
+}
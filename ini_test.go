@@ -0,0 +1,108 @@
+package confik
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseINIFile(t *testing.T) {
+	input := `; comment
+top = value
+[database]
+host = localhost
+port: 5432 ; inline comment
+url = postgres://localhost:5432/app#main
+empty =
+double = "say \"hi\"\n"
+single = 'p@ss;word'
+[cache.redis-cluster]  ; comment
+max-conns = 10
+`
+	kv, err := parseINIFile(strings.NewReader(input))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		"TOP":                           "value",
+		"DATABASE_HOST":                 "localhost",
+		"DATABASE_PORT":                 "5432",
+		"DATABASE_URL":                  "postgres://localhost:5432/app#main",
+		"DATABASE_EMPTY":                "",
+		"DATABASE_DOUBLE":               "say \"hi\"\n",
+		"DATABASE_SINGLE":               "p@ss;word",
+		"CACHE_REDIS_CLUSTER_MAX_CONNS": "10",
+	}, kv)
+}
+
+func TestParseINIFileErrors(t *testing.T) {
+	tests := map[string]string{
+		"[database":                      "invalid expression in ini file at line 1: unterminated section header [database",
+		"[database] x":                   "invalid expression in ini file at line 1: unexpected characters after section header [database]",
+		"[d@tabase]":                     "invalid expression in ini file at line 1: invalid section [d@tabase]",
+		"\nhost":                         "invalid expression in ini file at line 2: expected '=' after host",
+		"= value":                        "invalid expression in ini file at line 1: expected key before '='",
+		"h@st = value":                   "invalid expression in ini file at line 1: invalid key h@st",
+		"host = \"localhost":             "invalid expression in ini file at line 1: unterminated quoted value \"localhost",
+		"host = 'a' b":                   "invalid expression in ini file at line 1: unexpected characters after quoted value 'a'",
+		"[db]\nhost = a\n[db]\nhost = b": "invalid expression in ini file at line 4: duplicate key DB_HOST (first defined at line 2)",
+	}
+	for input, expected := range tests {
+		_, err := parseINIFile(strings.NewReader(input))
+		if assert.Error(t, err, input) {
+			assert.Equal(t, expected, err.Error(), input)
+		}
+	}
+}
+
+func TestINIFileSource(t *testing.T) {
+	source, err := NewINIFileSource("testdata/ini/config.ini")
+	assert.Nil(t, err)
+	assert.Equal(t, "testdata/ini/config.ini", source.Path)
+	assert.Equal(t, "ini file testdata/ini/config.ini", source.String())
+	value, exists := source.Lookup("DATABASE_REPLICA_MAX_CONNS")
+	assert.True(t, exists)
+	assert.Equal(t, "10", value)
+
+	_, err = NewINIFileSource("testdata/ini/missing.ini")
+	if assert.Error(t, err) {
+		assert.Equal(t, "ini file does not exist: testdata/ini/missing.ini", err.Error())
+	}
+	_, err = NewINIFileSource("testdata/ini")
+	if assert.Error(t, err) {
+		assert.Equal(t, "ini file is a directory: testdata/ini", err.Error())
+	}
+}
+
+type testINIConfig struct {
+	Name     string
+	Database struct {
+		Host     string
+		Port     uint16
+		User     string
+		Password string
+		Replica  struct {
+			Host     string
+			MaxConns int
+		}
+	}
+}
+
+func TestLoadFromEnvINIFile(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DATABASE_HOST", "db.internal")
+	source, err := NewINIFileSource("testdata/ini/config.ini")
+	assert.Nil(t, err)
+	cfg, err := LoadFromEnv(Config[testINIConfig]{
+		UseEnvFile: false,
+		Sources:    []Source{EnvSource{}, source},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "app", cfg.Name)
+	assert.Equal(t, "db.internal", cfg.Database.Host)
+	assert.Equal(t, uint16(5432), cfg.Database.Port)
+	assert.Equal(t, `root "admin"`, cfg.Database.User)
+	assert.Equal(t, "p@ss;word", cfg.Database.Password)
+	assert.Equal(t, "replica", cfg.Database.Replica.Host)
+	assert.Equal(t, 10, cfg.Database.Replica.MaxConns)
+}
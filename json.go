@@ -0,0 +1,308 @@
+package confik
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeyMapping will convert the path of keys to a value in a config file into the name of a variable.
+//
+// Values are skipped if the name is empty. The elements of an array of objects use their index as the key.
+type KeyMapping func(keys []string) string
+
+// EnvNameKeys is a [KeyMapping] for files where the keys are the names of the variables:
+//
+//	{"DATABASE": {"HOST": "localhost"}} // DATABASE_HOST
+func EnvNameKeys(keys []string) string {
+	return strings.Join(keys, "_")
+}
+
+// SnakeCaseKeys is a [KeyMapping] for files where the keys are snake_case (or kebab-case):
+//
+//	{"database": {"max_conns": 10}} // DATABASE_MAX_CONNS
+func SnakeCaseKeys(keys []string) string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		names[i] = strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+	}
+	return strings.Join(names, "_")
+}
+
+// FieldNameKeys is a [KeyMapping] for files where the keys are the names of the fields of the struct:
+//
+//	{"Database": {"MaxConns": 10}} // DATABASE_MAX_CONNS
+func FieldNameKeys(keys []string) string {
+	names := make([]string, len(keys))
+	for i, key := range keys {
+		// indexes are not split into digits
+		if _, err := strconv.Atoi(key); err == nil {
+			names[i] = key
+		} else {
+			names[i] = toEnvName(key)
+		}
+	}
+	return strings.Join(names, "_")
+}
+
+// JSONTagKeys will create a [KeyMapping] for files where the keys match the json tags of the fields of T (or the
+// names of the fields without a json tag) like [encoding/json].
+//
+// Each key is mapped to the name of the variable for the field (including the names and prefixes in env tags).
+func JSONTagKeys[T any](cfg Config[T]) (KeyMapping, error) {
+	loader, err := NewLoader(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return func(keys []string) string {
+		return loader.plan.jsonEnvName(keys, "")
+	}, nil
+}
+
+// jsonFieldName will return the name of a field in json (empty for embedded structs that are inlined).
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name != "" {
+		return name
+	}
+	if field.Anonymous && field.Type.Kind() == reflect.Struct {
+		return ""
+	}
+	return field.Name
+}
+
+// jsonEnvName will find the name of the variable for the field at the path of json keys (or "").
+//
+// The prefix is prepended to the names in the plan for the elements of slices and maps of nested structs.
+func (p *structPlan) jsonEnvName(keys []string, prefix string) string {
+	if len(keys) == 0 {
+		return ""
+	}
+	for _, fp := range p.fields {
+		// search the fields of inlined embedded structs
+		if fp.jsonName == "" {
+			if fp.nested != nil && fp.typ.Kind() == reflect.Struct {
+				if name := fp.nested.jsonEnvName(keys, prefix); name != "" {
+					return name
+				}
+			}
+			continue
+		}
+		if fp.jsonName == "-" || !strings.EqualFold(fp.jsonName, keys[0]) {
+			continue
+		}
+
+		rest := keys[1:]
+		switch {
+		case fp.nested == nil:
+			if len(rest) == 0 {
+				return prefix + fp.Name
+			}
+		case fp.typ.Kind() == reflect.Struct || fp.typ.Kind() == reflect.Pointer:
+			return fp.nested.jsonEnvName(rest, prefix)
+		case len(rest) > 1:
+			// the first key is the index (or key) of the element
+			return fp.nested.jsonEnvName(rest[1:], prefix+fp.elemPrefix+strings.ToUpper(rest[0])+"_")
+		}
+		return ""
+	}
+	return ""
+}
+
+// JSONFileSource is a [Source] backed by a JSON config file.
+//
+// The nested keys of the file are converted into the names of variables with a [KeyMapping]. Arrays of values are
+// joined into a comma separated list and arrays of objects are indexed (like UPSTREAMS_0_HOST). Objects of values
+// are also joined into a list of key:value pairs (for map fields) while other objects and arrays of objects are
+// available as raw JSON for fields with format=json. Elements containing a separator are quoted, so the fields
+// loading them need the quoted flag. The file is read once when the source is created.
+type JSONFileSource struct {
+	MapSource        // the values flattened from the file
+	Path      string // path to the JSON file
+}
+
+// NewJSONFileSource will create a new [JSONFileSource] by reading the JSON file at path.
+//
+// Add the source after an [EnvSource] in [Config] so environment variables take precedence over the file.
+func NewJSONFileSource(path string, mapping KeyMapping) (*JSONFileSource, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("json file does not exist: %s", path)
+	} else if err != nil {
+		return nil, fmt.Errorf("json file could not be read: %w", err)
+	}
+	if !json.Valid(data) {
+		var syntaxErr *json.SyntaxError
+		if err := json.Unmarshal(data, new(any)); errors.As(err, &syntaxErr) {
+			return nil, fmt.Errorf("invalid json file %s at offset %d: %w", path, syntaxErr.Offset, err)
+		}
+		return nil, fmt.Errorf("invalid json file %s", path)
+	}
+	if first := bytes.TrimSpace(data); len(first) == 0 || first[0] != '{' {
+		return nil, fmt.Errorf("invalid json file %s: expected an object", path)
+	}
+
+	f := jsonFlattener{
+		mapping: mapping,
+		values:  make(map[string]string),
+		paths:   make(map[string]string),
+	}
+	if err := f.flatten(data, nil); err != nil {
+		return nil, fmt.Errorf("invalid json file %s: %w", path, err)
+	}
+	return &JSONFileSource{
+		MapSource: f.values,
+		Path:      path,
+	}, nil
+}
+
+// String will describe the source.
+func (s *JSONFileSource) String() string {
+	return "json file " + s.Path
+}
+
+// jsonFlattener will flatten the nested values of a JSON document into variables.
+type jsonFlattener struct {
+	mapping KeyMapping        // converts the path of keys into the name of a variable
+	values  map[string]string // the values of the variables
+	paths   map[string]string // the dotted path of keys for each variable (to detect duplicates)
+}
+
+// set will set the variable for the path of keys (if the mapping has a name for it).
+func (f *jsonFlattener) set(keys []string, value string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	name := f.mapping(keys)
+	if name == "" {
+		return nil
+	}
+	path := strings.Join(keys, ".")
+	if other, exists := f.paths[name]; exists {
+		return fmt.Errorf("%s and %s both map to %s", other, path, name)
+	}
+	f.paths[name] = path
+	f.values[name] = value
+	return nil
+}
+
+// flatten will set the variables for the value at the path of keys.
+func (f *jsonFlattener) flatten(data json.RawMessage, keys []string) error {
+	data = bytes.TrimSpace(data)
+	switch data[0] {
+	case '{':
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			return err
+		}
+		// flatten in a consistent order so the errors are deterministic
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		value, ok := jsonPairs(object, names)
+		if !ok {
+			value = string(data)
+		}
+		if err := f.set(keys, value); err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := f.flatten(object[name], append(keys[:len(keys):len(keys)], name)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case '[':
+		var array []json.RawMessage
+		if err := json.Unmarshal(data, &array); err != nil {
+			return err
+		}
+		if list, ok := jsonList(array); ok {
+			return f.set(keys, list)
+		}
+		if err := f.set(keys, string(data)); err != nil {
+			return err
+		}
+		for i, elem := range array {
+			if err := f.flatten(elem, append(keys[:len(keys):len(keys)], strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+	case 'n':
+		return nil
+	case '"':
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		return f.set(keys, value)
+	default:
+		// numbers and booleans are kept as written
+		return f.set(keys, string(data))
+	}
+}
+
+// jsonList will join an array of values (strings, numbers and booleans) into a comma separated list.
+//
+// Elements containing a separator, quote or surrounding whitespace are quoted (see [splitList] and the quoted flag).
+func jsonList(array []json.RawMessage) (string, bool) {
+	elements := make([]string, len(array))
+	for i, elem := range array {
+		value, ok := jsonScalar(elem)
+		if !ok {
+			return "", false
+		}
+		elements[i] = quoteElement(value)
+	}
+	return strings.Join(elements, ","), true
+}
+
+// jsonPairs will join an object of values (strings, numbers and booleans) into a comma separated list of key:value
+// pairs in the order of names (see [parseMap]).
+//
+// Objects with keys containing ":" are not joined as the key could not be split from the value.
+func jsonPairs(object map[string]json.RawMessage, names []string) (string, bool) {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		value, ok := jsonScalar(object[name])
+		if !ok || strings.Contains(name, ":") {
+			return "", false
+		}
+		pairs[i] = quoteElement(name + ":" + value)
+	}
+	return strings.Join(pairs, ","), true
+}
+
+// jsonScalar will convert a string, number or boolean into its value (other values are not scalars).
+func jsonScalar(elem json.RawMessage) (string, bool) {
+	elem = bytes.TrimSpace(elem)
+	switch elem[0] {
+	case '{', '[', 'n':
+		return "", false
+	case '"':
+		var value string
+		if err := json.Unmarshal(elem, &value); err != nil {
+			return "", false
+		}
+		return value, true
+	default:
+		return string(elem), true
+	}
+}
+
+// quoteElement will quote an element of a list if it contains a separator, quote or surrounding whitespace.
+func quoteElement(value string) string {
+	if strings.ContainsAny(value, `,"`) || strings.TrimSpace(value) != value {
+		return `"` + strings.ReplaceAll(value, `"`, `""`) + `"`
+	}
+	return value
+}
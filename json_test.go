@@ -0,0 +1,160 @@
+package confik
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyMappings(t *testing.T) {
+	assert.Equal(t, "DATABASE_HOST", EnvNameKeys([]string{"DATABASE", "HOST"}))
+	assert.Equal(t, "DATABASE_MAX_CONNS", SnakeCaseKeys([]string{"database", "max-conns"}))
+	assert.Equal(t, "DATABASE_MAX_CONNS", FieldNameKeys([]string{"Database", "MaxConns"}))
+	assert.Equal(t, "UPSTREAMS_12_HOST", FieldNameKeys([]string{"Upstreams", "12", "Host"}))
+}
+
+func TestJSONFileSource(t *testing.T) {
+	source, err := NewJSONFileSource("testdata/json/config.json", SnakeCaseKeys)
+	assert.Nil(t, err)
+	assert.Equal(t, "testdata/json/config.json", source.Path)
+	assert.Equal(t, "json file testdata/json/config.json", source.String())
+
+	expected := map[string]string{
+		"NAME":               "app",
+		"PORT":               "8080",
+		"DEBUG":              "true",
+		"TAGS":               `a,"b,c"`,
+		"DATABASE_HOST":      "localhost",
+		"DATABASE_MAX_CONNS": "10",
+		"UPSTREAMS_0_HOST":   "a.com",
+		"UPSTREAMS_0_PORT":   "80",
+		"UPSTREAMS_1_HOST":   "b.com",
+		"LIMITS_API":         "100",
+	}
+	for key, value := range expected {
+		actual, exists := source.Lookup(key)
+		assert.True(t, exists, key)
+		assert.Equal(t, value, actual, key)
+	}
+	_, exists := source.Lookup("UNUSED")
+	assert.False(t, exists)
+
+	// objects of values are available as a list of key:value pairs (like a map)
+	value, exists := source.Lookup("LIMITS")
+	assert.True(t, exists)
+	assert.Equal(t, "api:100", value)
+	value, exists = source.Lookup("METADATA_LABELS")
+	assert.True(t, exists)
+	assert.Equal(t, "tier:web", value)
+
+	// other objects are available as raw json
+	value, exists = source.Lookup("METADATA")
+	assert.True(t, exists)
+	assert.Equal(t, `{"owner": "ops, web", "labels": {"tier": "web"}}`, value)
+}
+
+func TestJSONFileSourceErrors(t *testing.T) {
+	_, err := NewJSONFileSource("testdata/json/missing.json", SnakeCaseKeys)
+	if assert.Error(t, err) {
+		assert.Equal(t, "json file does not exist: testdata/json/missing.json", err.Error())
+	}
+	_, err = NewJSONFileSource("testdata/json/invalid.json", SnakeCaseKeys)
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid json file testdata/json/invalid.json at offset 16: invalid character '}' looking for beginning of object key string", err.Error())
+	}
+	_, err = NewJSONFileSource("testdata/json/array.json", SnakeCaseKeys)
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid json file testdata/json/array.json: expected an object", err.Error())
+	}
+	_, err = NewJSONFileSource("testdata/json/config.json", func(keys []string) string {
+		return "SAME"
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid json file testdata/json/config.json: database and database.host both map to SAME", err.Error())
+	}
+}
+
+type testJSONConfig struct {
+	Name     string
+	Port     uint16
+	Debug    bool
+	Tags     []string `env:"TAGS,quoted"`
+	Timeout  string   `env:"TIMEOUT,default=5s"`
+	Database struct {
+		Host     string `json:"hostname"`
+		MaxConns int    `env:"CONNS" json:"max_conns"`
+	} `env:"DB"`
+	Upstreams []struct {
+		Host string
+		Port uint16 `env:"PORT,optional"`
+	}
+	Limits map[string]int `env:"LIMITS"`
+}
+
+func TestLoadFromEnvJSONFile(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("PORT", "9090")
+	mapping, err := JSONTagKeys(Config[testJSONConfig]{})
+	assert.Nil(t, err)
+	source, err := NewJSONFileSource("testdata/json/config.json", func(keys []string) string {
+		// the json tags in the file use "host" instead of "hostname"
+		if len(keys) == 2 && keys[0] == "database" && keys[1] == "host" {
+			keys = []string{"database", "hostname"}
+		}
+		return mapping(keys)
+	})
+	assert.Nil(t, err)
+	value, exists := source.Lookup("DB_CONNS")
+	assert.True(t, exists)
+	assert.Equal(t, "10", value)
+
+	loader, err := NewLoader(Config[testJSONConfig]{
+		UseEnvFile: false,
+		Sources:    []Source{EnvSource{}, source},
+	})
+	assert.Nil(t, err)
+	cfg, origins, err := loader.LoadWithOrigins()
+	assert.Nil(t, err)
+	assert.Equal(t, "app", cfg.Name)
+	assert.Equal(t, uint16(9090), cfg.Port)
+	assert.True(t, cfg.Debug)
+	assert.Equal(t, []string{"a", "b,c"}, cfg.Tags)
+	assert.Equal(t, "localhost", cfg.Database.Host)
+	assert.Equal(t, 10, cfg.Database.MaxConns)
+	if assert.Equal(t, 2, len(cfg.Upstreams)) {
+		assert.Equal(t, uint16(80), cfg.Upstreams[0].Port)
+		assert.Equal(t, "b.com", cfg.Upstreams[1].Host)
+	}
+	assert.Equal(t, map[string]int{"api": 100}, cfg.Limits)
+
+	assert.Contains(t, origins, Origin{Field: "Name", EnvName: "NAME", Source: "json file testdata/json/config.json"})
+	assert.Contains(t, origins, Origin{Field: "Port", EnvName: "PORT", Source: OriginEnvironment})
+	assert.Contains(t, origins, Origin{Field: "Timeout", EnvName: "TIMEOUT", Source: OriginDefaultTag})
+	assert.Contains(t, origins, Origin{Field: "Upstreams[1].Host", EnvName: "UPSTREAMS_1_HOST", Source: "json file testdata/json/config.json"})
+}
+
+func TestJSONPairs(t *testing.T) {
+	object := map[string]json.RawMessage{
+		"a": json.RawMessage(`"x,y"`),
+		"b": json.RawMessage(`true`),
+	}
+	value, ok := jsonPairs(object, []string{"a", "b"})
+	assert.True(t, ok)
+	assert.Equal(t, `"a:x,y",b:true`, value)
+
+	_, ok = jsonPairs(map[string]json.RawMessage{"a:b": json.RawMessage(`1`)}, []string{"a:b"})
+	assert.False(t, ok)
+	_, ok = jsonPairs(map[string]json.RawMessage{"a": json.RawMessage(`[1]`)}, []string{"a"})
+	assert.False(t, ok)
+}
+
+func TestLoadFromEnvJSONFileFieldNames(t *testing.T) {
+	os.Clearenv()
+	source, err := NewJSONFileSource("testdata/json/fields.json", FieldNameKeys)
+	assert.Nil(t, err)
+	value, exists := source.Lookup("DATABASE_MAX_CONNS")
+	assert.True(t, exists)
+	assert.Equal(t, "10", value)
+}
@@ -35,11 +35,101 @@
 //
 //   - optional: Dont require this value to exist in the environment.
 //   - unset: Remove this environment value after load.
+//   - file: The environment value is the path to a file containing the value.
+//   - static: Reject reloads (see [Watcher]) that change this value.
+//   - quoted: Allow the elements of a slice or the pairs of a map to be quoted like CSV ("a,b",c).
 //
 // Available settings:
 //
 //   - default=value: Set the default (string) value if it is not found in the environment.
-//   - validator=validator: Set the name of the validator to use for this field.
+//   - validate=validator: Set the validators to use for this field (see Validators).
+//   - prefix=PREFIX_: Set the prefix for the fields of a nested struct (an empty value removes the prefix).
+//   - sep=;: Set the separator between the elements of a slice or the pairs of a map (otherwise ",").
+//   - kvsep=/: Set the separator between the key and value of a map (otherwise ":").
+//   - format=json: Decode the value with [encoding/json] into any type (like a struct, map, slice or pointer).
+//   - format=base64 or format=hex: Decode the value of a []byte field.
+//   - desc=description: Set the help text of the command-line flag for this field (see [BindFlags]).
+//   - min=1 or max=10: Require the parsed value to be at least or at most the bound (see below).
+//   - len=3: Require the parsed string, slice or map to have an exact length.
+//   - oneof=debug|info|warn: Require the parsed value to be one of the options.
+//   - regex=^[a-z]+$: Require the string value to match the regular expression (see below).
+//   - required_if=TLS_ENABLED:true: Require this value when another field has the (parsed) value.
+//   - required_with=TLS_CERT: Require this value when any of the other fields (separated by "|") are set.
+//   - oneof_group=auth: Require exactly one of the fields in the group to be set.
+//
+// The constraints are checked after the value is parsed: numbers are compared numerically, durations by their
+// length (like min=1s) and strings, slices and maps by their length. The options of oneof are parsed like the
+// value so oneof=80|443 on a uint16 field matches 0443. The value of a setting can contain "=" (like
+// regex=^a=b$) but not "," as it separates the flags and settings of the tag, so a repetition like {2,5} must be
+// written another way (or checked with a validator). Failures are reported as [ErrValidation]:
+//
+//	LOG_LEVEL=trace must be one of debug, info, warn
+//
+// The names in required_if and required_with refer to other fields in the same struct (without the prefix of a
+// nested struct) and fields with these rules are only required by them. A field is set when its variable exists or
+// it has a default. The rules are checked once every field of the struct is loaded and each broken rule is reported
+// in the [LoadError]:
+//
+//	type MyStruct struct {
+//	  TLSEnabled bool   `env:"TLS_ENABLED,default=false"`
+//	  TLSCert    string `env:"TLS_CERT,required_if=TLS_ENABLED:true"`
+//	  TLSKey     string `env:"TLS_KEY,required_with=TLS_CERT"`
+//	  APIKey     string `env:"API_KEY,oneof_group=auth"`
+//	  OAuthToken string `env:"OAUTH_TOKEN,oneof_group=auth"`
+//	}
+//
+// # Slices and Maps
+//
+// Slices and arrays are parsed from a comma separated list and maps are parsed from a list of key/value pairs:
+//
+//	type MyStruct struct {
+//	  Hosts  []string        // HOSTS=a.com,b.com
+//	  Point  [2]float64      // POINT=1.5,2 (must have exactly 2 elements)
+//	  Limits map[string]int  // LIMITS=api:100,web:50
+//	}
+//
+// The elements of a slice and the keys and values of a map use the same parsers as other fields. Whitespace around
+// each element is removed and, with the quoted flag, elements can be quoted like CSV to contain the separator
+// ("a,b",c). Duplicate keys are reported as an error and an empty value creates an empty map.
+//
+// # Nested Structs
+//
+// Struct fields without a parser are loaded recursively. The environment variable names of the nested fields
+// are prefixed by the name of the parent field:
+//
+//	type Database struct {
+//	  Host string // DATABASE_HOST
+//	  Port uint16 // DATABASE_PORT
+//	}
+//
+//	type MyStruct struct {
+//	  Database Database
+//	  Replica  Database `env:"REPLICA,prefix=RO_"` // RO_HOST, RO_PORT
+//	}
+//
+// Embedded structs without a tag share the prefix of their parent. The default and validate settings (and the
+// constraints) apply to single values so they are reported as an invalid tag on a nested struct.
+//
+// Slices of structs are loaded from numbered variables. The indexes are found in the sources and must be numbered
+// from 0 without gaps:
+//
+//	type MyStruct struct {
+//	  Upstreams []Database // UPSTREAMS_0_HOST, UPSTREAMS_0_PORT, UPSTREAMS_1_HOST, ...
+//	}
+//
+// Maps of structs are loaded from variables named by key. A key is found for each variable in the sources that
+// ends with the name of a field of the struct:
+//
+//	type MyStruct struct {
+//	  Databases map[string]Database // DATABASES_PRIMARY_HOST, DATABASES_EU_WEST_HOST, ...
+//	}
+//
+// # Pointers
+//
+// Pointers to any of the supported types are allocated when the environment variable exists. Optional fields
+// that are not found in the environment are left as nil, which allows an absent value to be distinguished from
+// the zero value. Pointers to nested structs are always allocated unless they are optional and none of their
+// variables exist.
 //
 // # Validators
 //
@@ -55,120 +145,195 @@
 //   - hostport: Verify that the value is a host/port combination.
 //   - cidr: Verify that the value is a CIDR.
 //
+// Multiple validators are separated by ";" and run in order, with every failure reported. Validators separated by
+// "|" pass when any of them passes and a validator prefixed by "!" passes when it fails:
+//
+//	type MyStruct struct {
+//	  Bind   string `env:"BIND,validate=ip|hostport"` // 10.0.0.1 or localhost:8080
+//	  LogDir string `env:"LOG_DIR,validate=!file;dir"`
+//	}
+//
+// # Secret Files
+//
+// Container platforms mount secrets as files. Set UseFileSuffix in [Config] (the default) to read the value of a
+// variable from the file named by NAME_FILE when NAME does not exist:
+//
+//	DB_PASSWORD_FILE=/run/secrets/db_password
+//
+// The trailing newline of the file is removed. Files that cannot be read are reported as [ErrParse].
+//
+// # Sources
+//
+// Values are read from the process environment by default. An ordered list of [Source] can be specified in
+// [Config] to read from other places (like an in-memory [MapSource] or an [EnvFileSource]) without touching the
+// process environment. The first source that contains a variable wins.
+//
+// A [DirSource] reads the files in a directory (like a Kubernetes ConfigMap or Secret volume) where each file name
+// is a variable and the contents of the file is the value. With AutoReload the "..data" symlink is checked once at
+// the start of each load so every field is read from the same version of the volume.
+//
+// A [JSONFileSource] reads a JSON config file. The keys of the file are converted into the names of variables with a
+// [KeyMapping] ([EnvNameKeys], [SnakeCaseKeys], [FieldNameKeys] or the json tags of the struct with [JSONTagKeys]).
+// Add it after an [EnvSource] so environment variables override the file field by field:
+//
+//	source, err := NewJSONFileSource("config.json", SnakeCaseKeys)
+//	...
+//	loader, err := NewLoader(Config[MyStruct]{
+//	  Sources: []Source{EnvSource{}, source},
+//	})
+//
+// An [INIFileSource] reads an INI file where the keys in a section are prefixed by the name of the section
+// ([database] followed by host=x is DATABASE_HOST).
+//
+// Use [Loader.LoadWithOrigins] to find which source set the value of each field.
+//
+// Set EnvFileOverlay in [Config] to keep the variables from the environment file in memory instead of adding them
+// to the process environment. The variables are always kept in memory when Sources is set, after the sources (or
+// before them when EnvFileOverride is set).
+//
+// # Command-Line Flags
+//
+// [BindFlags] registers a flag for each field on a [flag.FlagSet] (DATABASE_URL is --database-url) and returns a
+// [Config] that reads from the flags that were set. Flags take precedence over the environment, the environment file
+// and the defaults:
+//
+//	cfg, err := BindFlags(flag.CommandLine, DefaultConfig[MyStruct]())
+//	...
+//	flag.Parse()
+//	value, err := LoadFromEnv(cfg)
+//
+// # Layered Environment Files
+//
+// Set EnvFiles in [Config] to load an ordered list of environment files where later files override earlier ones.
+// Files that do not exist are skipped. "{profile}" in a file name is replaced by EnvProfile (or the value of the
+// variable named by EnvProfileVar) and files containing "{profile}" are skipped when there is no profile:
+//
+//	cfg, err := LoadFromEnv(Config[MyStruct]{
+//	  UseEnvFile:    true,
+//	  EnvFiles:      DefaultEnvFiles, // .env, .env.local, .env.{profile}, .env.{profile}.local
+//	  EnvProfileVar: "APP_ENV",
+//	})
+//
+// # Variable Expansion
+//
+// Set ExpandVariables in [Config] to expand references to other variables in the values of the environment file
+// and in default tags:
+//
+//	DATABASE_URL=postgres://${DB_USER}@${DB_HOST:-localhost}/$DB_NAME
+//
+// References are resolved against the environment after the environment file is loaded. Single quoted values in
+// the environment file are never expanded and a reference can be escaped with a backslash (\$NAME). Circular
+// references are reported as an error and references to variables that do not exist are replaced with an empty
+// string (or reported as an error if StrictExpansion is set). Default tags that fail to expand are reported as
+// [ErrParse].
+//
+// # Hot Reload
+//
+// A [Watcher] reloads the configuration on an interval or signal (like SIGHUP) and publishes the new value
+// atomically. Subscribers are told which fields changed. Reloads that fail to load or change a field with the
+// "static" flag are rejected and the last good configuration is kept. The environment file is always kept in memory
+// (see EnvFileOverlay) so edits to it are seen by each reload.
+//
+// # Repeated Loads
+//
+// A [Loader] parses the tags of T and resolves the validators and parsers for each field once so it can be used to
+// load a T many times (like in tests or reload loops). Invalid tags are reported when the Loader is created:
+//
+//	loader, err := NewLoader(DefaultConfig[MyStruct]())
+//	...
+//	cfg, err := loader.Load()
+//
+// # Errors
+//
+// All the fields are loaded before an error is returned. Every problem is reported in a single [LoadError]
+// containing a [FieldError] for each field. The kind of failure can be checked with [errors.Is] using
+// [ErrMissing], [ErrInvalidTag], [ErrValidation] or [ErrParse].
+//
 // # Custom Validators
 //
 // Fields can be implement custom validators by specifying a [Validator] in [Config].
 //
+// A [Validator] checks the string value before it is parsed. To check the parsed value (like a [time.Duration] or
+// [url.URL]) wrap a [TypedValidator] with [NewValueValidator] and register it by name in ValueValidators (used with
+// validate=name) or by type in TypeValidators (run on every field of the type). Validators of parsed values cannot
+// be combined with "|" or "!".
+//
+// Structs that implement [StructValidator] have their Validate method called once all of their fields (including
+// nested structs) are loaded without errors, so rules between fields can live next to the type:
+//
+//	func (s *Server) Validate() error {
+//	  if s.ReadTimeout >= s.WriteTimeout {
+//	    return errors.New("read timeout must be less than write timeout")
+//	  }
+//	  return nil
+//	}
+//
+// Failures are reported as [ErrValidation].
+//
 // See the examples below.
 //
 // # Custom Types
 //
 // Custom types can be supported by specifying a [Parser] in [Config].
 //
+// Types that implement [encoding.TextUnmarshaler] or [flag.Value] on a pointer receiver (like [net.IP]) are parsed
+// without registering a [Parser], including as the elements of a slice. Set UseBinaryUnmarshaler in [Config] to
+// also parse types that implement [encoding.BinaryUnmarshaler].
+//
 // See the examples below.
 //
 // # Examples
 package confik
 
-import (
-	"fmt"
-	"os"
-	"reflect"
-)
-
 // LoadFromEnv will build a T by reading values from environment files and variables.
+//
+// Errors for the fields of T are returned as a [*LoadError]. Use a [Loader] to load a T repeatedly.
 func LoadFromEnv[T any](cfgs ...Config[T]) (*T, error) {
 	cfg := DefaultConfig[T]()
 	if len(cfgs) > 0 {
 		cfg = cfgs[0]
 	}
 
-	// attempt to find and load the ".env" file
-	if cfg.UseEnvFile {
-		_, err := loadEnvFile(cfg)
-		if err != nil {
-			return nil, err
-		}
+	loader, err := NewLoader(cfg)
+	if err != nil {
+		return nil, err
 	}
+	return loader.Load()
+}
 
-	var z T
-	var t = reflect.TypeOf(z)
-
-	// iterate over all the visible fields on the struct
-	for _, field := range reflect.VisibleFields(t) {
-		fieldConfig, err := newFieldConfig(cfg, field)
-		if err != nil {
-			return nil, err
-		}
-
-		// get a reflected value of the field
-		var rv = reflect.ValueOf(&z).Elem().FieldByName(field.Name)
-
-		// get the environment variable
-		fieldValue, exists := os.LookupEnv(fieldConfig.Name)
-
-		// unset the environment variable if applicable
-		if fieldConfig.Unset {
-			os.Unsetenv(fieldConfig.Name)
-		}
-
-		// handle default values if applicable
-		if !exists && cfg.DefaultValue != nil {
-			var drv = reflect.ValueOf(cfg.DefaultValue).Elem().FieldByName(field.Name)
-			rv.Set(drv)
-			continue
-		} else if !exists && fieldConfig.Default != nil {
-			fieldValue = *fieldConfig.Default
-			exists = true
-		}
-
-		// return an error if the environment variable doesn't exist and this field is not optional
-		if !fieldConfig.Optional && !exists {
-			return nil, fmt.Errorf("environment variable %s does not exist and has no default", fieldConfig.Name)
-		}
-
-		// skip to the next field if we cant find the environment variable
-		if !exists {
-			continue
-		}
-
-		// run validation on the environment variable (if any)
-		if fieldConfig.Validate != nil {
-			if err = (*fieldConfig.Validate)(fieldConfig.Name, fieldValue); err != nil {
-				return nil, err
-			}
-		}
-
-		// handle simple types and slices
-		var kind = rv.Kind()
-		if kind == reflect.Slice {
-			err := handleSlice(fieldConfig, fieldValue, rv)
-			if err != nil {
-				return nil, err
-			}
-			continue
-		}
-		kindParser, exists := kindParsers[kind]
-		if exists {
-			// convert the value from a string to the fields type
-			if err = kindParser(fieldConfig, fieldValue, rv); err != nil {
-				return nil, err
-			}
-			continue
-		}
+// loadSources will load the environment file (if applicable) and return the ordered list of sources to read from.
+//
+// When [Config.EnvFileOverlay] or [Config.Sources] is set the variables from the environment file are added as an in-memory source
+// for each file (see [envFileSources]) which take precedence over the other sources if [Config.EnvFileOverride] is set.
+func loadSources[T any](cfg Config[T]) ([]Source, error) {
+	sources := snapshotSources(cfg.sources())
 
-		// handle more complex types (like time.Time, time.Duration, custom types)
-		parsers := mergeMap(typeParsers, cfg.Parsers)
-		parser, exists := parsers[field.Type]
-		if exists {
-			// convert the value from a string to the fields type
-			if err = parser(fieldConfig, fieldValue, rv); err != nil {
-				return nil, err
+	// attempt to find and load the ".env" file
+	if !cfg.UseEnvFile {
+		return sources, nil
+	}
+	values, err := loadEnvFile(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.overlay() {
+		return sources, nil
+	}
+	if cfg.EnvFileOverride {
+		// command-line flags still take precedence over the environment file
+		flags := 0
+		for flags < len(sources) {
+			if _, ok := sources[flags].(*FlagSource); !ok {
+				break
 			}
-			continue
+			flags++
 		}
-
-		return nil, fmt.Errorf("field %s of type %s has no parser", field.Name, field.Type)
+		files := envFileSources(values)
+		overlay := make([]Source, 0, len(sources)+len(files))
+		overlay = append(overlay, sources[:flags]...)
+		overlay = append(overlay, files...)
+		return append(overlay, sources[flags:]...), nil
 	}
-	return &z, nil
+	// limit the capacity so the sources in the config are never modified by append
+	return append(sources[:len(sources):len(sources)], envFileSources(values)...), nil
 }
@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 	"unicode"
 
 	"github.com/stretchr/testify/assert"
@@ -128,7 +129,7 @@ func TestLoadFromEnvUnset(t *testing.T) {
 }
 
 type testParseUnknown struct {
-	Website MyCustomType
+	Website complex64
 }
 
 func TestLoadFromEnvUnknownType(t *testing.T) {
@@ -138,7 +139,7 @@ func TestLoadFromEnvUnknownType(t *testing.T) {
 		UseEnvFile: false,
 	})
 	if assert.Error(t, err) {
-		assert.Equal(t, "field Website of type confik.MyCustomType has no parser", err.Error())
+		assert.Equal(t, "field Website of type complex64 has no parser", err.Error())
 	}
 }
 
@@ -146,14 +147,18 @@ type testUnsupportedSlice struct {
 	Custom []MyCustomType
 }
 
+type testUnsupportedSliceElem struct {
+	Custom []complex64
+}
+
 func TestLoadFromEnvUnsupportedSlice(t *testing.T) {
 	os.Clearenv()
 	os.Setenv("CUSTOM", "Hello")
-	_, err := LoadFromEnv(Config[testUnsupportedSlice]{
+	_, err := LoadFromEnv(Config[testUnsupportedSliceElem]{
 		UseEnvFile: false,
 	})
 	if assert.Error(t, err) {
-		assert.Equal(t, "CUSTOM is invalid: []confik.MyCustomType is not supported", err.Error())
+		assert.Equal(t, "CUSTOM is invalid: []complex64 is not supported", err.Error())
 	}
 }
 
@@ -200,7 +205,14 @@ func TestLoadFromEnvRequiredFields(t *testing.T) {
 		EnvFileOverride: false,
 	})
 	if assert.Error(t, err) {
-		assert.Equal(t, "environment variable A_STRING_LIST does not exist and has no default", err.Error())
+		assert.ErrorIs(t, err, ErrMissing)
+		var loadErr *LoadError
+		if assert.ErrorAs(t, err, &loadErr) {
+			assert.Equal(t, 13, len(loadErr.Errors))
+			assert.Equal(t, "environment variable A_STRING_LIST does not exist and has no default", loadErr.Errors[0].Error())
+			assert.Equal(t, "AStringList", loadErr.Errors[0].Field)
+			assert.Equal(t, "A_STRING_LIST", loadErr.Errors[0].EnvName)
+		}
 	}
 }
 
@@ -240,6 +252,375 @@ func TestLoadFromEnvFoundEnvIsDir(t *testing.T) {
 	}
 }
 
+type testNestedDatabase struct {
+	Host string
+	Port uint16 `env:"PORT,default=5432"`
+}
+
+type testNestedEmbedded struct {
+	Region string
+}
+
+type testNested struct {
+	testNestedEmbedded
+	Database testNestedDatabase
+	Replica  testNestedDatabase `env:"REPLICA,prefix=RO_"`
+	Primary  testNestedDatabase `env:"PRIMARY,prefix="`
+	Cache    struct {
+		Inner struct {
+			Size int
+		}
+	}
+}
+
+func TestLoadFromEnvNested(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("REGION", "us-east-1")
+	os.Setenv("DATABASE_HOST", "db")
+	os.Setenv("RO_HOST", "replica")
+	os.Setenv("RO_PORT", "5433")
+	os.Setenv("HOST", "primary")
+	os.Setenv("CACHE_INNER_SIZE", "10")
+	cfg, err := LoadFromEnv(Config[testNested]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "us-east-1", cfg.Region)
+	assert.Equal(t, "db", cfg.Database.Host)
+	assert.Equal(t, uint16(5432), cfg.Database.Port)
+	assert.Equal(t, "replica", cfg.Replica.Host)
+	assert.Equal(t, uint16(5433), cfg.Replica.Port)
+	assert.Equal(t, "primary", cfg.Primary.Host)
+	assert.Equal(t, 10, cfg.Cache.Inner.Size)
+}
+
+func TestLoadFromEnvNestedDefaultValue(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("REGION", "us-east-1")
+	os.Setenv("DATABASE_HOST", "db")
+	cfg, err := LoadFromEnv(Config[testNested]{
+		UseEnvFile: false,
+		DefaultValue: &testNested{
+			Replica: testNestedDatabase{Host: "replica", Port: 1},
+			Primary: testNestedDatabase{Host: "primary", Port: 2},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "db", cfg.Database.Host)
+	assert.Equal(t, "replica", cfg.Replica.Host)
+	assert.Equal(t, uint16(1), cfg.Replica.Port)
+	assert.Equal(t, "primary", cfg.Primary.Host)
+	assert.Equal(t, 0, cfg.Cache.Inner.Size)
+}
+
+type testNestedError struct {
+	Cache struct {
+		Inner struct {
+			Size complex64
+		}
+	}
+}
+
+func TestLoadFromEnvNestedError(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("CACHE_INNER_SIZE", "10")
+	_, err := LoadFromEnv(Config[testNestedError]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "field Cache.Inner.Size of type complex64 has no parser", err.Error())
+	}
+}
+
+type testNestedInvalidTag struct {
+	Database struct {
+		Host string `env:"@@"`
+	}
+}
+
+func TestLoadFromEnvNestedInvalidTag(t *testing.T) {
+	os.Clearenv()
+	_, err := LoadFromEnv(Config[testNestedInvalidTag]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid tag on field Database.Host: invalid env tag: invalid environment variable name: @@ must be [A-Z0-9_]+", err.Error())
+	}
+}
+
+type testPointers struct {
+	Timeout  *time.Duration `env:"TIMEOUT,optional"`
+	Retries  *int           `env:"RETRIES,optional"`
+	Website  *url.URL       `env:"WEBSITE,optional"`
+	Missing  *int           `env:"MISSING,optional"`
+	Default  *string        `env:"DEFAULT,default=hello"`
+	Database *testNestedDatabase
+}
+
+func TestLoadFromEnvPointers(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("TIMEOUT", "5s")
+	os.Setenv("RETRIES", "0")
+	os.Setenv("WEBSITE", "https://google.com")
+	os.Setenv("DATABASE_HOST", "db")
+	cfg, err := LoadFromEnv(Config[testPointers]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+	if assert.NotNil(t, cfg.Timeout) {
+		assert.Equal(t, 5*time.Second, *cfg.Timeout)
+	}
+	if assert.NotNil(t, cfg.Retries) {
+		assert.Equal(t, 0, *cfg.Retries)
+	}
+	if assert.NotNil(t, cfg.Website) {
+		assert.Equal(t, "google.com", cfg.Website.Host)
+	}
+	assert.Nil(t, cfg.Missing)
+	if assert.NotNil(t, cfg.Default) {
+		assert.Equal(t, "hello", *cfg.Default)
+	}
+	if assert.NotNil(t, cfg.Database) {
+		assert.Equal(t, "db", cfg.Database.Host)
+		assert.Equal(t, uint16(5432), cfg.Database.Port)
+	}
+}
+
+type testOptionalPointer struct {
+	Primary *testNestedDatabase `env:"PRIMARY,optional"`
+	Replica *testNestedDatabase `env:"REPLICA,optional"`
+}
+
+func TestLoadFromEnvOptionalPointer(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("REPLICA_HOST", "replica")
+	cfg, err := LoadFromEnv(Config[testOptionalPointer]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, cfg.Primary)
+	if assert.NotNil(t, cfg.Replica) {
+		assert.Equal(t, "replica", cfg.Replica.Host)
+	}
+
+	// the fields are still required once any of the variables exist
+	os.Clearenv()
+	os.Setenv("PRIMARY_PORT", "5432")
+	_, err = LoadFromEnv(Config[testOptionalPointer]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "environment variable PRIMARY_HOST does not exist and has no default", err.Error())
+	}
+
+	// a default value is loaded instead of leaving the pointer as nil
+	os.Clearenv()
+	cfg, err = LoadFromEnv(Config[testOptionalPointer]{
+		UseEnvFile:   false,
+		DefaultValue: &testOptionalPointer{Primary: &testNestedDatabase{Host: "db"}},
+	})
+	assert.Nil(t, err)
+	if assert.NotNil(t, cfg.Primary) {
+		assert.Equal(t, "db", cfg.Primary.Host)
+	}
+	assert.Nil(t, cfg.Replica)
+}
+
+type testPointerError struct {
+	Retries *int       `env:"RETRIES"`
+	Unknown *complex64 `env:"UNKNOWN,optional"`
+}
+
+func TestLoadFromEnvPointerError(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("RETRIES", "abc")
+	_, err := LoadFromEnv(Config[testPointerError]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "RETRIES=abc is not a valid int: strconv.ParseInt: parsing \"abc\": invalid syntax", err.Error())
+	}
+
+	os.Setenv("RETRIES", "1")
+	os.Setenv("UNKNOWN", "1")
+	_, err = LoadFromEnv(Config[testPointerError]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "field Unknown of type complex64 has no parser", err.Error())
+	}
+}
+
+type testAggregateErrors struct {
+	Missing  string
+	Port     string `env:"PORT,validate=port"`
+	Retries  int
+	Optional int `env:"OPTIONAL,optional"`
+}
+
+func TestLoadFromEnvAggregateErrors(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("PORT", "abc")
+	os.Setenv("RETRIES", "many")
+	_, err := LoadFromEnv(Config[testAggregateErrors]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.NotErrorIs(t, err, ErrInvalidTag)
+		assert.ErrorIs(t, err, ErrMissing)
+		assert.ErrorIs(t, err, ErrValidation)
+		assert.ErrorIs(t, err, ErrParse)
+
+		var loadErr *LoadError
+		if assert.ErrorAs(t, err, &loadErr) {
+			assert.Equal(t, 3, len(loadErr.Errors))
+			assert.Equal(t, FieldError{
+				Field:   "Port",
+				EnvName: "PORT",
+				Value:   "abc",
+				Kind:    ErrValidation,
+				Err:     loadErr.Errors[1].Err,
+			}, *loadErr.Errors[1])
+			assert.Equal(t, "many", loadErr.Errors[2].Value)
+			assert.ErrorIs(t, loadErr.Errors[2], ErrParse)
+		}
+
+		var fieldErr *FieldError
+		if assert.ErrorAs(t, err, &fieldErr) {
+			assert.Equal(t, "Missing", fieldErr.Field)
+		}
+
+		expected := `3 errors occurred while loading the environment:
+  - environment variable MISSING does not exist and has no default
+  - PORT=abc is not a valid port: 0-65535
+  - RETRIES=many is not a valid int: strconv.ParseInt: parsing "many": invalid syntax`
+		assert.Equal(t, expected, err.Error())
+	}
+}
+
+func TestLoadFromEnvEnvFileOverlay(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("INT16", "42")
+	cfg, err := LoadFromEnv(Config[testAllTypes]{
+		UseEnvFile:     true,
+		EnvFileOverlay: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int16(42), cfg.Aint16)
+	assert.Equal(t, "A custom name", cfg.CustomName)
+	_, exists := os.LookupEnv("A_CUSTOM_NAME")
+	assert.False(t, exists)
+}
+
+func TestLoadFromEnvEnvFileOverlayOverride(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("INT16", "42")
+	cfg, err := LoadFromEnv(Config[testAllTypes]{
+		UseEnvFile:      true,
+		EnvFileOverlay:  true,
+		EnvFileOverride: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int16(-32768), cfg.Aint16)
+	value, _ := os.LookupEnv("INT16")
+	assert.Equal(t, "42", value)
+}
+
+func TestLoadFromEnvEnvFileOverlaySources(t *testing.T) {
+	t.Parallel()
+	sources := []Source{MapSource{"WEBSITE": "https://example.com"}}
+	cfg, err := LoadFromEnv(Config[testCustomValidator]{
+		UseEnvFile:     true,
+		EnvFilePath:    "testdata/.uri",
+		EnvFileOverlay: true,
+		Sources:        sources,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://example.com", cfg.Website)
+	assert.Equal(t, 1, len(sources))
+}
+
+func TestLoadFromEnvEnvFileSources(t *testing.T) {
+	os.Clearenv()
+	cfg, err := LoadFromEnv(Config[testCustomValidator]{
+		UseEnvFile:  true,
+		EnvFilePath: "testdata/.uri",
+		Sources:     []Source{MapSource{"OPTIONAL": "true"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "https://google.com/my_site", cfg.Website)
+	assert.True(t, cfg.Optional)
+
+	// the environment file is read into memory as the sources may not read the process environment
+	_, exists := os.LookupEnv("WEBSITE")
+	assert.False(t, exists)
+}
+
+type testFileSuffix struct {
+	DbPassword string
+	ApiKey     string `env:"API_KEY,file"`
+	Override   string `env:"OVERRIDE"`
+	Missing    string `env:"MISSING,optional"`
+}
+
+func TestLoadFromEnvFileSuffix(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DB_PASSWORD_FILE", "testdata/secrets/db_password")
+	os.Setenv("API_KEY", "testdata/secrets/api_key")
+	os.Setenv("OVERRIDE", "value")
+	os.Setenv("OVERRIDE_FILE", "testdata/secrets/db_password")
+	cfg, err := LoadFromEnv(Config[testFileSuffix]{
+		UseEnvFile:    false,
+		UseFileSuffix: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "hunter2", cfg.DbPassword)
+	assert.Equal(t, "api-key", cfg.ApiKey)
+	assert.Equal(t, "value", cfg.Override)
+	assert.Equal(t, "", cfg.Missing)
+
+	_, err = LoadFromEnv(Config[testFileSuffix]{
+		UseEnvFile:    false,
+		UseFileSuffix: false,
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "environment variable DB_PASSWORD does not exist and has no default", err.Error())
+	}
+}
+
+func TestLoadFromEnvFileSuffixError(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DB_PASSWORD_FILE", "testdata/secrets/fake")
+	os.Setenv("API_KEY", "testdata/secrets/api_key")
+	os.Setenv("OVERRIDE", "value")
+	_, err := LoadFromEnv(Config[testFileSuffix]{
+		UseEnvFile:    false,
+		UseFileSuffix: true,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrParse)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		assert.Equal(t, "DB_PASSWORD=testdata/secrets/fake could not be read: open testdata/secrets/fake: no such file or directory", err.Error())
+	}
+}
+
+type testFileSuffixUnset struct {
+	Secret string `env:"SECRET,unset"`
+}
+
+func TestLoadFromEnvFileSuffixUnset(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("SECRET_FILE", "testdata/secrets/db_password")
+	cfg, err := LoadFromEnv(Config[testFileSuffixUnset]{
+		UseEnvFile:    false,
+		UseFileSuffix: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "hunter2", cfg.Secret)
+	_, exists := os.LookupEnv("SECRET_FILE")
+	assert.False(t, exists)
+}
+
 type benchSimple struct {
 	A string
 }
@@ -361,6 +742,29 @@ func Example_customParser() {
 	// Output: Bob
 }
 
+func Example_nested() {
+	os.Clearenv()
+	os.Setenv("DATABASE_HOST", "localhost")
+	os.Setenv("DATABASE_PORT", "5432")
+
+	type DatabaseConfig struct {
+		Host string
+		Port uint16
+	}
+	type ExampleConfig struct {
+		Database DatabaseConfig
+	}
+
+	cfg, _ := LoadFromEnv(Config[ExampleConfig]{
+		UseEnvFile: false,
+	})
+
+	fmt.Println(cfg.Database.Host)
+	fmt.Println(cfg.Database.Port)
+	// Output: localhost
+	// 5432
+}
+
 func Example_defaultTag() {
 	os.Clearenv()
 
@@ -438,3 +842,194 @@ func BenchmarkLoadFromEnvValidate(b *testing.B) {
 		}
 	}
 }
+
+type testUpstream struct {
+	Host string
+	Port uint16 `env:"PORT,default=80"`
+	TLS  struct {
+		Enabled bool `env:"ENABLED,optional"`
+	} `env:"TLS"`
+}
+
+type testIndexedList struct {
+	Upstreams []testUpstream
+	Backups   []*testUpstream `env:"BACKUPS,optional,prefix=BK_"`
+}
+
+func TestLoadFromEnvIndexedList(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("UPSTREAMS_0_HOST", "a.com")
+	os.Setenv("UPSTREAMS_0_PORT", "8080")
+	os.Setenv("UPSTREAMS_1_HOST", "b.com")
+	os.Setenv("UPSTREAMS_1_TLS_ENABLED", "true")
+	os.Setenv("BK_0_HOST", "c.com")
+	cfg, err := LoadFromEnv(Config[testIndexedList]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(cfg.Upstreams)) {
+		assert.Equal(t, "a.com", cfg.Upstreams[0].Host)
+		assert.Equal(t, uint16(8080), cfg.Upstreams[0].Port)
+		assert.False(t, cfg.Upstreams[0].TLS.Enabled)
+		assert.Equal(t, "b.com", cfg.Upstreams[1].Host)
+		assert.Equal(t, uint16(80), cfg.Upstreams[1].Port)
+		assert.True(t, cfg.Upstreams[1].TLS.Enabled)
+	}
+	if assert.Equal(t, 1, len(cfg.Backups)) {
+		assert.Equal(t, "c.com", cfg.Backups[0].Host)
+	}
+}
+
+func TestLoadFromEnvIndexedListErrors(t *testing.T) {
+	os.Clearenv()
+	_, err := LoadFromEnv(Config[testIndexedList]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrMissing)
+		assert.Equal(t, "environment variables UPSTREAMS_0_* do not exist and have no default", err.Error())
+	}
+
+	os.Setenv("UPSTREAMS_0_HOST", "a.com")
+	os.Setenv("UPSTREAMS_2_HOST", "c.com")
+	_, err = LoadFromEnv(Config[testIndexedList]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "environment variables UPSTREAMS_1_* do not exist: indexes of UPSTREAMS must be numbered from 0 without gaps", err.Error())
+		var loadErr *LoadError
+		if assert.ErrorAs(t, err, &loadErr) {
+			assert.Equal(t, "Upstreams[1]", loadErr.Errors[0].Field)
+		}
+	}
+
+	os.Setenv("UPSTREAMS_1_PORT", "abc")
+	_, err = LoadFromEnv(Config[testIndexedList]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		expected := `2 errors occurred while loading the environment:
+  - environment variable UPSTREAMS_1_HOST does not exist and has no default
+  - UPSTREAMS_1_PORT=abc is not a valid uint16: strconv.ParseUint: parsing "abc": invalid syntax`
+		assert.Equal(t, expected, err.Error())
+		var loadErr *LoadError
+		if assert.ErrorAs(t, err, &loadErr) {
+			assert.Equal(t, "Upstreams[1].Host", loadErr.Errors[0].Field)
+			assert.Equal(t, "Upstreams[1].Port", loadErr.Errors[1].Field)
+		}
+	}
+}
+
+type testInvalidListTag struct {
+	Upstreams []struct {
+		Host string `env:"@@"`
+	}
+}
+
+func TestNewLoaderIndexedListInvalidTag(t *testing.T) {
+	_, err := NewLoader(Config[testInvalidListTag]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid tag on field Upstreams[].Host: invalid env tag: invalid environment variable name: @@ must be [A-Z0-9_]+", err.Error())
+	}
+}
+
+type testRecursiveList struct {
+	Name     string
+	Children []testRecursiveList
+}
+
+func TestNewLoaderRecursiveList(t *testing.T) {
+	_, err := NewLoader(Config[testRecursiveList]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrInvalidTag)
+		assert.Equal(t, "field Children of type confik.testRecursiveList is recursive", err.Error())
+	}
+}
+
+type testDatabase struct {
+	Host     string
+	Port     uint16 `env:"PORT,default=5432,validate=port"`
+	Password string `env:"PASSWORD,optional"`
+}
+
+type testKeyedMap struct {
+	Databases map[string]testDatabase
+	Caches    map[string]*testDatabase `env:"CACHES,optional"`
+}
+
+func TestLoadFromEnvKeyedMap(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("DATABASES_PRIMARY_HOST", "a.com")
+	os.Setenv("DATABASES_PRIMARY_PORT", "5433")
+	os.Setenv("DATABASES_EU_WEST_HOST", "b.com")
+	os.Setenv("DATABASES_EU_WEST_PASSWORD_FILE", "testdata/secrets/db_password")
+	cfg, err := LoadFromEnv(Config[testKeyedMap]{
+		UseEnvFile:    false,
+		UseFileSuffix: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]testDatabase{
+		"PRIMARY": {Host: "a.com", Port: 5433},
+		"EU_WEST": {Host: "b.com", Port: 5432, Password: "hunter2"},
+	}, cfg.Databases)
+	assert.Nil(t, cfg.Caches)
+}
+
+func TestLoadFromEnvKeyedMapErrors(t *testing.T) {
+	os.Clearenv()
+	_, err := LoadFromEnv(Config[testKeyedMap]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrMissing)
+		assert.Equal(t, "environment variables DATABASES_<KEY>_* do not exist and have no default", err.Error())
+	}
+
+	os.Setenv("DATABASES_PRIMARY_PORT", "99999")
+	_, err = LoadFromEnv(Config[testKeyedMap]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		expected := `2 errors occurred while loading the environment:
+  - environment variable DATABASES_PRIMARY_HOST does not exist and has no default
+  - DATABASES_PRIMARY_PORT=99999 is not a valid port: 0-65535`
+		assert.Equal(t, expected, err.Error())
+		var loadErr *LoadError
+		if assert.ErrorAs(t, err, &loadErr) {
+			assert.Equal(t, "Databases[PRIMARY].Host", loadErr.Errors[0].Field)
+		}
+	}
+}
+
+func TestFindKeys(t *testing.T) {
+	sources := []Source{MapSource{
+		"DATABASES_A_HOST":      "",
+		"DATABASES_B_TLS_HOST":  "",
+		"DATABASES_C_PORT_FILE": "",
+		"DATABASES__HOST":       "",
+		"DATABASES_D_UNKNOWN":   "",
+		"OTHER_E_HOST":          "",
+	}}
+	keys := findKeys(sources, "DATABASES_", []string{"TLS_HOST", "HOST", "PORT"}, true)
+	assert.Equal(t, []string{"A", "B", "C"}, keys)
+	keys = findKeys(sources, "DATABASES_", []string{"TLS_HOST", "HOST", "PORT"}, false)
+	assert.Equal(t, []string{"A", "B"}, keys)
+}
+
+// loadErrorMessages will get the message of each error in the [LoadError] returned by a load.
+func loadErrorMessages(t *testing.T, err error) []string {
+	t.Helper()
+	var loadErr *LoadError
+	if !assert.ErrorAs(t, err, &loadErr) {
+		return nil
+	}
+	var messages []string
+	for _, fieldErr := range loadErr.Errors {
+		messages = append(messages, fieldErr.Error())
+	}
+	return messages
+}
@@ -0,0 +1,770 @@
+package confik
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Loader[T] will build a T by reading values from environment files and variables.
+//
+// The struct tags, validators and parsers for T are resolved once when the Loader is created so each call to
+// [Loader.Load] only reads and converts the values. A Loader is safe for concurrent use.
+type Loader[T any] struct {
+	cfg  Config[T]
+	plan *structPlan
+}
+
+// structPlan is the compiled plan for loading the fields of a struct.
+type structPlan struct {
+	fields   []*fieldPlan
+	path     string // dotted path of the struct (relative to the element of a slice or map of structs)
+	validate bool   // does the struct implement StructValidator?
+	rules    []rule // conditional requirements between the fields (like required_if)
+}
+
+// fieldPlan is the compiled plan for loading a single field of a struct.
+type fieldPlan struct {
+	*FieldConfig              // the configuration for the field
+	index        int          // index of the field within the struct
+	typ          reflect.Type // type of the field
+	nested       *structPlan  // plan for a nested struct (or a pointer, slice or slice of pointers to a nested struct)
+	elemPrefix   string       // prefix for the variables of the elements of a slice or map of nested structs (like UPSTREAMS_)
+	elemNames    []string     // names of the variables of the elements of a map of nested structs or of an optional pointer to a nested struct (longest first)
+	jsonName     string       // name of the field in json (empty for embedded structs that are inlined)
+	parser       Parser       // parser for the type of the field (nil if there is no parser)
+	constraints  []constraint // constraints on the parsed value of the field (like min=1)
+}
+
+// compiler will compile the plans for loading structs.
+type compiler struct {
+	validators      map[string]Validator            // the built-in and custom validators
+	valueValidators map[string]ValueValidator       // the custom validators of parsed values
+	typeValidators  map[reflect.Type]ValueValidator // the custom validators of parsed values for each type
+	parsers         map[reflect.Type]Parser         // the built-in and custom type parsers
+	binary          bool                            // use encoding.BinaryUnmarshaler for types that implement it?
+	visiting        map[reflect.Type]bool           // structs currently being compiled (to detect recursive types)
+	scope           string                          // path of the slice of structs being compiled (used in error messages)
+}
+
+// NewLoader will create a new [Loader] for T.
+//
+// Invalid tags and unknown validators on the fields of T are returned as a [*LoadError].
+func NewLoader[T any](cfg Config[T]) (*Loader[T], error) {
+	c := compiler{
+		validators:      mergeMap(fieldValidators, cfg.Validators),
+		valueValidators: cfg.ValueValidators,
+		typeValidators:  cfg.TypeValidators,
+		parsers:         mergeMap(typeParsers, cfg.Parsers),
+		binary:          cfg.UseBinaryUnmarshaler,
+		visiting:        make(map[reflect.Type]bool),
+	}
+	var errs LoadError
+	plan := c.compileStruct(reflect.TypeOf((*T)(nil)).Elem(), "", "", &errs)
+	if len(errs.Errors) > 0 {
+		return nil, &errs
+	}
+	return &Loader[T]{
+		cfg:  cfg,
+		plan: plan,
+	}, nil
+}
+
+// isNestedStruct will determine if a field of type t should be loaded as a nested struct.
+//
+// Structs with a parser (like [time.Time], [url.URL] or types that implement [encoding.TextUnmarshaler]) are loaded
+// as a single value.
+func (c *compiler) isNestedStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	_, exists := c.parsers[t]
+	return !exists && c.unmarshalerFor(t) == nil
+}
+
+// compileStruct will compile the plan for loading the fields of the struct t.
+//
+// The prefix is prepended to the environment variable names and the path is the dotted path of the struct
+// from the root (used in error messages).
+func (c *compiler) compileStruct(t reflect.Type, prefix string, path string, errs *LoadError) *structPlan {
+	plan := structPlan{
+		path:     path,
+		validate: reflect.PointerTo(t).Implements(structValidatorType),
+	}
+	if c.visiting[t] {
+		errPath := joinFieldPath(c.scope, path)
+		errs.add(errPath, "", "", ErrInvalidTag, fmt.Errorf("field %s of type %s is recursive", errPath, t))
+		return &plan
+	}
+	c.visiting[t] = true
+	defer delete(c.visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !isLoadable(field) {
+			continue
+		}
+		fieldPath := joinFieldPath(path, field.Name)
+
+		// the path of fields within a slice of structs is relative to the element
+		errPath := joinFieldPath(c.scope, fieldPath)
+		fieldConfig, err := newFieldConfig(c.validators, c.valueValidators, field, prefix, errPath)
+		if err != nil {
+			errs.add(errPath, "", "", ErrInvalidTag, err)
+			continue
+		}
+		fieldConfig.Field = fieldPath
+		fp := &fieldPlan{
+			FieldConfig: fieldConfig,
+			index:       i,
+			typ:         field.Type,
+			jsonName:    jsonFieldName(field),
+		}
+
+		// compile nested structs (pointers to nested structs are allocated unless they are optional)
+		if fieldConfig.Format != nil {
+			parser, err := c.formatParser(field.Type, *fieldConfig.Format)
+			if err != nil {
+				errs.add(errPath, fieldConfig.Name, "", ErrInvalidTag, fmt.Errorf("invalid tag on field %s: %w", errPath, err))
+				continue
+			}
+			fp.parser = parser
+		} else if c.isNestedStruct(field.Type) {
+			fp.nested = c.compileStruct(field.Type, fieldConfig.childPrefix(field, prefix), fieldPath, errs)
+		} else if field.Type.Kind() == reflect.Pointer && c.isNestedStruct(field.Type.Elem()) {
+			fp.nested = c.compileStruct(field.Type.Elem(), fieldConfig.childPrefix(field, prefix), fieldPath, errs)
+			if fieldConfig.Optional {
+				fp.elemNames = fp.nested.names()
+			}
+		} else if elem := listElem(field.Type); elem != nil && c.isNestedStruct(elem) && c.isMapKey(field.Type) {
+			// the elements are compiled without a prefix or path as they depend on the index or key (like UPSTREAMS_0_)
+			scope := c.scope
+			c.scope = errPath + "[]"
+			if c.visiting[elem] {
+				errs.add(errPath, "", "", ErrInvalidTag, fmt.Errorf("field %s of type %s is recursive", errPath, elem))
+			} else {
+				fp.elemPrefix = fieldConfig.childPrefix(field, prefix)
+				fp.nested = c.compileStruct(elem, "", "", errs)
+				if field.Type.Kind() == reflect.Map {
+					fp.parser = c.parserFor(field.Type.Key())
+					fp.elemNames = fp.nested.names()
+				}
+			}
+			c.scope = scope
+		} else {
+			fp.parser = c.parserFor(field.Type)
+		}
+
+		// compile the checks on the parsed value (like min=1 or validators of parsed values)
+		if fp.nested == nil {
+			fp.constraints, err = c.compileChecks(fieldConfig, field.Type, fp.parser)
+		} else {
+			err = c.nestedSettingsError(fieldConfig, field.Type)
+		}
+		if err != nil {
+			errs.add(errPath, fieldConfig.Name, "", ErrInvalidTag, fmt.Errorf("invalid tag on field %s: %w", errPath, err))
+			continue
+		}
+		plan.fields = append(plan.fields, fp)
+	}
+	c.compileRules(&plan, prefix, errs)
+	return &plan
+}
+
+// nestedSettingsError will return an error if the tag of a field of nested structs of type t (a struct, pointer,
+// slice or map) has settings that only apply to a single value.
+func (c *compiler) nestedSettingsError(fc *FieldConfig, t reflect.Type) error {
+	switch {
+	case fc.Default != nil:
+		return fmt.Errorf("default is not supported for nested structs")
+	case fc.Validators != nil:
+		return fmt.Errorf("validate is not supported for nested structs")
+	case fc.Optional && t.Kind() == reflect.Struct:
+		// pointers, slices and maps of nested structs are optional when none of their variables exist
+		return fmt.Errorf("optional is not supported for nested structs (use a pointer)")
+	case c.hasChecks(fc):
+		return fmt.Errorf("constraints are not supported for nested structs")
+	}
+	return nil
+}
+
+// hasChecks will check if the tag of a field has any checks on the parsed value.
+func (c *compiler) hasChecks(fc *FieldConfig) bool {
+	if fc.Min != nil || fc.Max != nil || fc.Len != nil || fc.OneOf != nil || fc.Regex != nil {
+		return true
+	}
+	for _, expr := range fc.Validators {
+		if _, exists := c.valueValidators[expr]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+// compileChecks will compile the checks on the parsed value of a field of type t.
+//
+// The constraints in the tag are followed by the validators of parsed values named in the tag and the validator
+// registered for the type (pointers are dereferenced).
+func (c *compiler) compileChecks(fc *FieldConfig, t reflect.Type, parser Parser) ([]constraint, error) {
+	checks, err := compileConstraints(fc, t, parser)
+	if err != nil {
+		return nil, err
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for _, expr := range fc.Validators {
+		if validator, exists := c.valueValidators[expr]; exists {
+			check, err := compileValueValidator(expr, validator, t)
+			if err != nil {
+				return nil, err
+			}
+			checks = append(checks, check)
+		}
+	}
+	if validator, exists := c.typeValidators[t]; exists {
+		check, err := compileValueValidator(t.String(), validator, t)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// listElem will return the struct type of the elements of a slice or map of structs (or pointers to structs) or nil.
+func listElem(t reflect.Type) reflect.Type {
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Map {
+		return nil
+	}
+	elem := t.Elem()
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return nil
+	}
+	return elem
+}
+
+// isMapKey will check if the keys of t can be parsed when t is a map (other types are always true).
+func (c *compiler) isMapKey(t reflect.Type) bool {
+	return t.Kind() != reflect.Map || c.parserFor(t.Key()) != nil
+}
+
+// names will return the names of the variables in the plan (longest first).
+//
+// The variables of slices and maps of nested structs are skipped as their names are not known until loaded.
+func (p *structPlan) names() []string {
+	var names []string
+	for _, fp := range p.fields {
+		switch {
+		case fp.nested == nil:
+			names = append(names, fp.Name)
+		case fp.typ.Kind() == reflect.Struct || fp.typ.Kind() == reflect.Pointer:
+			names = append(names, fp.nested.names()...)
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return len(names[i]) > len(names[j])
+	})
+	return names
+}
+
+// parserFor will find the parser for the type t (or nil if there is no parser).
+//
+// Pointers are allocated and the value is parsed into the element they point to.
+func (c *compiler) parserFor(t reflect.Type) Parser {
+	// handle more complex types (like time.Time, time.Duration, custom types)
+	// these are checked first as they may share a kind with the simple types (time.Duration is an int64)
+	if parser, exists := c.parsers[t]; exists {
+		return parser
+	}
+	if parser := c.unmarshalerFor(t); parser != nil {
+		return parser
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		elemParser := c.parserFor(t.Elem())
+		if elemParser == nil {
+			return nil
+		}
+		return pointerParser(t, elemParser)
+	case reflect.Slice, reflect.Array:
+		elemParser := c.parserFor(t.Elem())
+		if elemParser == nil {
+			return func(fc *FieldConfig, fieldValue string, rv reflect.Value) error {
+				return fmt.Errorf("%s is invalid: %s is not supported", fc.Name, rv.Type())
+			}
+		}
+		return func(fc *FieldConfig, fieldValue string, rv reflect.Value) error {
+			return parseSlice(fc, fieldValue, rv, elemParser)
+		}
+	case reflect.Map:
+		keyParser, valueParser := c.parserFor(t.Key()), c.parserFor(t.Elem())
+		if keyParser == nil || valueParser == nil {
+			return nil
+		}
+		return func(fc *FieldConfig, fieldValue string, rv reflect.Value) error {
+			return parseMap(fc, fieldValue, rv, keyParser, valueParser)
+		}
+	}
+
+	// handle simple types
+	return kindParsers[t.Kind()]
+}
+
+// formatParser will find the parser for the format setting of a field of type t.
+//
+// JSON can be decoded into any type while base64 and hex require a []byte (or a pointer to one).
+func (c *compiler) formatParser(t reflect.Type, format string) (Parser, error) {
+	if format == "json" {
+		return parseJSON, nil
+	}
+	if t.Kind() == reflect.Pointer {
+		elemParser, err := c.formatParser(t.Elem(), format)
+		if err != nil {
+			return nil, err
+		}
+		return pointerParser(t, elemParser), nil
+	}
+	if t.Kind() != reflect.Slice || t.Elem().Kind() != reflect.Uint8 {
+		return nil, fmt.Errorf("format %s requires a []byte but found %s", format, t)
+	}
+	return formatParsers[format], nil
+}
+
+// pointerParser will create a parser that allocates a pointer of type t and parses the value into the element with
+// elemParser.
+func pointerParser(t reflect.Type, elemParser Parser) Parser {
+	return func(fc *FieldConfig, fieldValue string, rv reflect.Value) error {
+		ptr := reflect.New(t.Elem())
+		if err := elemParser(fc, fieldValue, ptr.Elem()); err != nil {
+			return err
+		}
+		rv.Set(ptr)
+		return nil
+	}
+}
+
+// unmarshalerFor will find the parser for a type that implements [encoding.TextUnmarshaler], [flag.Value] or
+// [encoding.BinaryUnmarshaler] (if enabled) on a pointer receiver (or nil if there is no parser).
+//
+// Pointers are skipped so they can be allocated before the value they point to is parsed.
+func (c *compiler) unmarshalerFor(t reflect.Type) Parser {
+	if t.Kind() == reflect.Pointer {
+		return nil
+	}
+	ptr := reflect.PointerTo(t)
+	switch {
+	case ptr.Implements(textUnmarshalerType):
+		return parseTextUnmarshaler
+	case c.binary && ptr.Implements(binaryUnmarshalerType):
+		return parseBinaryUnmarshaler
+	case ptr.Implements(flagValueType):
+		return parseFlagValue
+	}
+	return nil
+}
+
+// loadState is the state of a single call to [Loader.Load].
+type loadState struct {
+	sources      []Source  // the ordered list of sources to read values from
+	errs         LoadError // the errors for each field
+	trackOrigins bool      // record where the value of each field was loaded from?
+	origins      []Origin  // where the value of each field was loaded from
+}
+
+// addOrigin will record where the value of a field was loaded from (if applicable).
+func (s *loadState) addOrigin(origin Origin) {
+	if s.trackOrigins {
+		s.origins = append(s.origins, origin)
+	}
+}
+
+// Load will build a T by reading values from environment files and variables.
+//
+// Errors for the fields of T are returned as a [*LoadError].
+func (l *Loader[T]) Load() (*T, error) {
+	value, _, err := l.load(false)
+	return value, err
+}
+
+// LoadWithOrigins will build a T like [Loader.Load] and return where the value of each field was loaded from.
+//
+// Fields that were not set (like optional fields that do not exist) have no [Origin].
+func (l *Loader[T]) LoadWithOrigins() (*T, []Origin, error) {
+	return l.load(true)
+}
+
+// load will build a T and record where the value of each field was loaded from (if applicable).
+func (l *Loader[T]) load(trackOrigins bool) (*T, []Origin, error) {
+	sources, err := loadSources(l.cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var z T
+	var defaults reflect.Value
+	if l.cfg.DefaultValue != nil {
+		defaults = reflect.ValueOf(l.cfg.DefaultValue).Elem()
+	}
+	state := loadState{sources: sources, trackOrigins: trackOrigins}
+	l.loadStruct(&state, l.plan, reflect.ValueOf(&z).Elem(), defaults, "", "")
+	if len(state.errs.Errors) > 0 {
+		return nil, nil, &state.errs
+	}
+	return &z, state.origins, nil
+}
+
+// loadStruct will load the fields of the struct rv from the environment.
+//
+// defaults is the matching struct within [Config.DefaultValue] (if any). The prefix and path are prepended to the
+// names and paths in the plan when loading the elements of a slice of structs (otherwise they are empty).
+func (l *Loader[T]) loadStruct(state *loadState, plan *structPlan, rv reflect.Value, defaults reflect.Value, prefix string, path string) {
+	errCount := len(state.errs.Errors)
+	var set []bool
+	if len(plan.rules) > 0 {
+		set = make([]bool, len(plan.fields))
+	}
+	for i, fp := range plan.fields {
+		// get a reflected value of the field (and its default)
+		var frv = rv.Field(fp.index)
+		var drv reflect.Value
+		if defaults.IsValid() {
+			drv = defaults.Field(fp.index)
+		}
+
+		if fp.nested == nil {
+			loaded := l.loadField(state, fp, frv, drv, prefix, path)
+			if set != nil {
+				set[i] = loaded
+			}
+			continue
+		}
+
+		// recurse into nested structs
+		switch fp.typ.Kind() {
+		case reflect.Struct:
+			l.loadStruct(state, fp.nested, frv, drv, prefix, path)
+		case reflect.Pointer:
+			ptr := reflect.New(fp.typ.Elem())
+			var pdrv reflect.Value
+			if drv.IsValid() && !drv.IsNil() {
+				pdrv = drv.Elem()
+			}
+			// optional pointers are left as nil when none of their variables exist (and there is no default)
+			if fp.Optional && !pdrv.IsValid() && !l.anyExists(state.sources, prefix, fp.elemNames) {
+				continue
+			}
+			l.loadStruct(state, fp.nested, ptr.Elem(), pdrv, prefix, path)
+			frv.Set(ptr)
+		case reflect.Slice:
+			l.loadList(state, fp, frv, drv, prefix, path)
+		case reflect.Map:
+			l.loadMap(state, fp, frv, drv, prefix, path)
+		}
+	}
+
+	// check the conditional requirements between the fields once they are all loaded
+	for _, rule := range plan.rules {
+		rule(rv, set, prefix, path, &state.errs)
+	}
+
+	// run the Validate method of the struct once its fields are loaded without errors
+	if plan.validate && len(state.errs.Errors) == errCount {
+		if err := rv.Addr().Interface().(StructValidator).Validate(); err != nil {
+			state.errs.add(joinFieldPath(path, plan.path), "", "", ErrValidation, err)
+		}
+	}
+}
+
+// anyExists will check if any of the variables named by prefix followed by one of the names exist in the sources
+// (including the NAME_FILE variables if applicable).
+func (l *Loader[T]) anyExists(sources []Source, prefix string, names []string) bool {
+	for _, name := range names {
+		if _, _, exists := findSource(sources, prefix+name); exists {
+			return true
+		}
+		if l.cfg.UseFileSuffix {
+			if _, _, exists := findSource(sources, prefix+name+fileSuffix); exists {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// loadList will load a slice of nested structs from indexed variables (like UPSTREAMS_0_HOST).
+//
+// The indexes are discovered from the keys of the sources and must be numbered from 0 without gaps.
+func (l *Loader[T]) loadList(state *loadState, fp *fieldPlan, rv reflect.Value, drv reflect.Value, prefix string, path string) {
+	listPrefix := prefix + fp.elemPrefix
+	fieldPath := joinFieldPath(path, fp.Field)
+	indexes := findIndexes(state.sources, listPrefix)
+
+	if len(indexes) == 0 {
+		if drv.IsValid() {
+			rv.Set(drv)
+			state.addOrigin(Origin{Field: fieldPath, EnvName: prefix + fp.Name, Source: OriginDefaultValue})
+		} else if !fp.Optional {
+			err := fmt.Errorf("environment variables %s0_* do not exist and have no default", listPrefix)
+			state.errs.add(fieldPath, prefix+fp.Name, "", ErrMissing, err)
+		}
+		return
+	}
+	for i, index := range indexes {
+		if i != index {
+			err := fmt.Errorf("environment variables %s%d_* do not exist: indexes of %s must be numbered from 0 without gaps", listPrefix, i, strings.TrimSuffix(listPrefix, "_"))
+			state.errs.add(fmt.Sprintf("%s[%d]", fieldPath, i), fmt.Sprintf("%s%d", listPrefix, i), "", ErrMissing, err)
+			return
+		}
+	}
+
+	list := reflect.MakeSlice(fp.typ, len(indexes), len(indexes))
+	for i := range indexes {
+		elemPrefix := listPrefix + strconv.Itoa(i) + "_"
+		elemPath := fmt.Sprintf("%s[%d]", fieldPath, i)
+		elem := list.Index(i)
+		if elem.Kind() == reflect.Pointer {
+			elem.Set(reflect.New(fp.typ.Elem().Elem()))
+			elem = elem.Elem()
+		}
+		l.loadStruct(state, fp.nested, elem, reflect.Value{}, elemPrefix, elemPath)
+	}
+	rv.Set(list)
+}
+
+// findIndexes will find the sorted indexes of the variables in the sources that start with prefix followed by
+// an index (like UPSTREAMS_0_HOST).
+func findIndexes(sources []Source, prefix string) []int {
+	found := make(map[int]bool)
+	for _, source := range sources {
+		for _, key := range source.Keys() {
+			digits, _, ok := strings.Cut(strings.TrimPrefix(key, prefix), "_")
+			if !ok || !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			index, err := strconv.Atoi(digits)
+			// skip indexes that are not written in the canonical form (like 01 or +1)
+			if err != nil || index < 0 || strconv.Itoa(index) != digits {
+				continue
+			}
+			found[index] = true
+		}
+	}
+	indexes := make([]int, 0, len(found))
+	for index := range found {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
+
+// loadMap will load a map of nested structs from variables named by key (like DATABASES_PRIMARY_HOST).
+//
+// The keys are discovered from the keys of the sources that end with the name of a variable of the nested struct.
+func (l *Loader[T]) loadMap(state *loadState, fp *fieldPlan, rv reflect.Value, drv reflect.Value, prefix string, path string) {
+	mapPrefix := prefix + fp.elemPrefix
+	fieldPath := joinFieldPath(path, fp.Field)
+	keys := findKeys(state.sources, mapPrefix, fp.elemNames, l.cfg.UseFileSuffix)
+
+	if len(keys) == 0 {
+		if drv.IsValid() {
+			rv.Set(drv)
+			state.addOrigin(Origin{Field: fieldPath, EnvName: prefix + fp.Name, Source: OriginDefaultValue})
+		} else if !fp.Optional {
+			err := fmt.Errorf("environment variables %s<KEY>_* do not exist and have no default", mapPrefix)
+			state.errs.add(fieldPath, prefix+fp.Name, "", ErrMissing, err)
+		}
+		return
+	}
+
+	data := reflect.MakeMapWithSize(fp.typ, len(keys))
+	for _, key := range keys {
+		elemPath := fmt.Sprintf("%s[%s]", fieldPath, key)
+		k := reflect.New(fp.typ.Key()).Elem()
+		keyConfig := *fp.FieldConfig
+		keyConfig.Name = mapPrefix + key
+		keyConfig.Field = elemPath
+		if err := fp.parser(&keyConfig, key, k); err != nil {
+			state.errs.add(elemPath, keyConfig.Name, key, ErrParse, err)
+			continue
+		}
+
+		elem := reflect.New(fp.typ.Elem()).Elem()
+		value := elem
+		if value.Kind() == reflect.Pointer {
+			value.Set(reflect.New(fp.typ.Elem().Elem()))
+			value = value.Elem()
+		}
+		l.loadStruct(state, fp.nested, value, reflect.Value{}, mapPrefix+key+"_", elemPath)
+		data.SetMapIndex(k, elem)
+	}
+	rv.Set(data)
+}
+
+// findKeys will find the sorted keys of the variables in the sources that start with prefix followed by a key and
+// one of the names (like DATABASES_PRIMARY_HOST).
+//
+// Names are matched longest first so the key is as short as possible.
+func findKeys(sources []Source, prefix string, names []string, useFileSuffix bool) []string {
+	found := make(map[string]bool)
+	for _, source := range sources {
+		for _, key := range source.Keys() {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			rest := key[len(prefix):]
+			k, ok := cutName(rest, names)
+			if !ok && useFileSuffix && strings.HasSuffix(rest, fileSuffix) {
+				k, ok = cutName(strings.TrimSuffix(rest, fileSuffix), names)
+			}
+			if ok {
+				found[k] = true
+			}
+		}
+	}
+	keys := make([]string, 0, len(found))
+	for key := range found {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cutName will remove the first of the names that value ends with (after a "_") and return the rest.
+func cutName(value string, names []string) (string, bool) {
+	for _, name := range names {
+		if rest, ok := strings.CutSuffix(value, "_"+name); ok && rest != "" {
+			return rest, true
+		}
+	}
+	return "", false
+}
+
+// loadField will load a single field from the environment into rv.
+//
+// The prefix and path are prepended to the name and path of the field (see [Loader.loadStruct]). The result is true
+// when the field has a value from a source or a default (even if the value failed to load).
+func (l *Loader[T]) loadField(state *loadState, fp *fieldPlan, rv reflect.Value, drv reflect.Value, prefix string, path string) bool {
+	fieldConfig := fp.FieldConfig
+	if prefix != "" || path != "" {
+		scoped := *fieldConfig
+		scoped.Name = prefix + scoped.Name
+		scoped.Field = joinFieldPath(path, scoped.Field)
+		fieldConfig = &scoped
+	}
+	sources := state.sources
+	errs := &state.errs
+
+	// get the environment variable from the first source that has it
+	fieldValue, source, exists := findSource(sources, fieldConfig.Name)
+	origin := Origin{Field: fieldConfig.Field, EnvName: fieldConfig.Name}
+
+	// the value is the path to a file when using the "file" flag
+	fromFile := fieldConfig.File
+
+	// fallback to reading the value from the file named by NAME_FILE (if applicable)
+	fileEnvName := fieldConfig.Name + fileSuffix
+	if !exists && l.cfg.UseFileSuffix {
+		fieldValue, source, exists = findSource(sources, fileEnvName)
+		fromFile = fromFile || exists
+		origin.EnvName = fileEnvName
+	}
+	if exists {
+		origin.Source = sourceName(source)
+	}
+
+	// unset the environment variable if applicable
+	if fieldConfig.Unset {
+		os.Unsetenv(fieldConfig.Name)
+		if l.cfg.UseFileSuffix {
+			os.Unsetenv(fileEnvName)
+		}
+	}
+
+	// handle default values if applicable
+	if !exists && drv.IsValid() {
+		rv.Set(drv)
+		state.addOrigin(Origin{Field: fieldConfig.Field, EnvName: fieldConfig.Name, Source: OriginDefaultValue})
+		return !drv.IsZero()
+	} else if !exists && fieldConfig.Default != nil {
+		fieldValue = *fieldConfig.Default
+		exists = true
+		origin = Origin{Field: fieldConfig.Field, EnvName: fieldConfig.Name, Source: OriginDefaultTag}
+
+		// expand references to other variables in the default tag (if applicable)
+		if l.cfg.ExpandVariables {
+			expanded, err := expandVariables(fieldValue, sourceLookup(sources), l.cfg.StrictExpansion)
+			if err != nil {
+				err = fmt.Errorf("failed to expand default value of %s: %w", fieldConfig.Name, err)
+				errs.add(fieldConfig.Field, fieldConfig.Name, fieldValue, ErrParse, err)
+				return true
+			}
+			fieldValue = expanded
+		}
+	}
+
+	// return an error if the environment variable doesn't exist and this field is not optional (fields with
+	// conditional requirements are checked once the struct is loaded)
+	if !fieldConfig.Optional && !fieldConfig.isConditional() && !exists {
+		err := fmt.Errorf("environment variable %s does not exist and has no default", fieldConfig.Name)
+		errs.add(fieldConfig.Field, fieldConfig.Name, "", ErrMissing, err)
+		return false
+	}
+
+	// skip to the next field if we cant find the environment variable
+	if !exists {
+		return false
+	}
+
+	// read the value from the file (if applicable)
+	if fromFile {
+		contents, err := readValueFile(fieldConfig.Name, fieldValue)
+		if err != nil {
+			errs.add(fieldConfig.Field, fieldConfig.Name, fieldValue, ErrParse, err)
+			return true
+		}
+		fieldValue = contents
+	}
+
+	// run validation on the environment variable (every failure is reported)
+	valid := true
+	for _, validate := range fieldConfig.Validate {
+		if err := validate(fieldConfig.Name, fieldValue); err != nil {
+			errs.add(fieldConfig.Field, fieldConfig.Name, fieldValue, ErrValidation, err)
+			valid = false
+		}
+	}
+	if !valid {
+		return true
+	}
+
+	// convert the value from a string to the fields type
+	if fp.parser == nil {
+		t := fp.typ
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		err := fmt.Errorf("field %s of type %s has no parser", fieldConfig.Field, t)
+		errs.add(fieldConfig.Field, fieldConfig.Name, fieldValue, ErrParse, err)
+		return true
+	}
+	if err := fp.parser(fieldConfig, fieldValue, rv); err != nil {
+		errs.add(fieldConfig.Field, fieldConfig.Name, fieldValue, ErrParse, err)
+		return true
+	}
+
+	// check the constraints on the parsed value (if any)
+	if err := checkConstraints(fp.constraints, fieldConfig, fieldValue, rv); err != nil {
+		errs.add(fieldConfig.Field, fieldConfig.Name, fieldValue, ErrValidation, err)
+		return true
+	}
+	state.addOrigin(origin)
+	return true
+}
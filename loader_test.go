@@ -0,0 +1,206 @@
+package confik
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testLoader struct {
+	Name     string
+	Port     uint16        `env:"PORT,validate=port"`
+	Timeout  time.Duration `env:"TIMEOUT,default=5s"`
+	Database struct {
+		Host string
+		Port *uint16 `env:"PORT,optional"`
+	}
+}
+
+func TestNewLoader(t *testing.T) {
+	os.Clearenv()
+	loader, err := NewLoader(Config[testLoader]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+
+	os.Setenv("NAME", "app")
+	os.Setenv("PORT", "8080")
+	os.Setenv("DATABASE_HOST", "localhost")
+	cfg, err := loader.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, "app", cfg.Name)
+	assert.Equal(t, uint16(8080), cfg.Port)
+	assert.Equal(t, 5*time.Second, cfg.Timeout)
+	assert.Equal(t, "localhost", cfg.Database.Host)
+	assert.Nil(t, cfg.Database.Port)
+
+	// the plan is reused but the values are read on each load
+	os.Setenv("DATABASE_PORT", "5432")
+	cfg, err = loader.Load()
+	assert.Nil(t, err)
+	if assert.NotNil(t, cfg.Database.Port) {
+		assert.Equal(t, uint16(5432), *cfg.Database.Port)
+	}
+}
+
+type testLoaderInvalidTags struct {
+	Name     string `env:"@@"`
+	Host     string `env:"HOST,validate=nope"`
+	Database struct {
+		Port uint16 `env:"PORT,bad=setting"`
+	}
+}
+
+func TestNewLoaderInvalidTags(t *testing.T) {
+	_, err := NewLoader(Config[testLoaderInvalidTags]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrInvalidTag)
+		var loadErr *LoadError
+		if assert.ErrorAs(t, err, &loadErr) {
+			assert.Equal(t, 3, len(loadErr.Errors))
+			assert.Equal(t, "Name", loadErr.Errors[0].Field)
+			assert.Equal(t, "Host", loadErr.Errors[1].Field)
+			assert.Equal(t, "Database.Port", loadErr.Errors[2].Field)
+		}
+	}
+}
+
+type testLoaderNestedSettings struct {
+	Database struct{ Host string }   `env:"DATABASE,optional"`
+	Replica  struct{ Host string }   `env:"REPLICA,default=x"`
+	Cache    *struct{ Host string }  `env:"CACHE,validate=uri"`
+	Backups  []struct{ Host string } `env:"BACKUPS,optional,default=x"`
+}
+
+func TestNewLoaderNestedSettings(t *testing.T) {
+	_, err := NewLoader(Config[testLoaderNestedSettings]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrInvalidTag)
+		assert.Equal(t, []string{
+			"invalid tag on field Database: optional is not supported for nested structs (use a pointer)",
+			"invalid tag on field Replica: default is not supported for nested structs",
+			"invalid tag on field Cache: validate is not supported for nested structs",
+			"invalid tag on field Backups: default is not supported for nested structs",
+		}, loadErrorMessages(t, err))
+	}
+}
+
+func TestLoaderConcurrentLoad(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("NAME", "app")
+	os.Setenv("PORT", "8080")
+	os.Setenv("DATABASE_HOST", "localhost")
+	loader, err := NewLoader(Config[testLoader]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cfg, err := loader.Load()
+			assert.Nil(t, err)
+			assert.Equal(t, "localhost", cfg.Database.Host)
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkLoaderLoad(b *testing.B) {
+	os.Clearenv()
+	os.Setenv("NAME", "app")
+	os.Setenv("PORT", "8080")
+	os.Setenv("DATABASE_HOST", "localhost")
+	loader, err := NewLoader(Config[testLoader]{
+		UseEnvFile: false,
+	})
+	if err != nil {
+		panic(err)
+	}
+	for n := 0; n < b.N; n++ {
+		_, err := loader.Load()
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func BenchmarkLoaderLoadFromEnv(b *testing.B) {
+	os.Clearenv()
+	os.Setenv("NAME", "app")
+	os.Setenv("PORT", "8080")
+	os.Setenv("DATABASE_HOST", "localhost")
+	cfg := Config[testLoader]{
+		UseEnvFile: false,
+	}
+	for n := 0; n < b.N; n++ {
+		_, err := LoadFromEnv(cfg)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+type testOrigins struct {
+	Name     string
+	Secret   string `env:"SECRET"`
+	Timeout  time.Duration
+	Optional string `env:"OPTIONAL,optional"`
+}
+
+func TestLoaderLoadWithOrigins(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("SECRET_FILE", "testdata/secrets/db_password")
+	loader, err := NewLoader(Config[testOrigins]{
+		UseEnvFile:    false,
+		UseFileSuffix: true,
+		Sources:       []Source{MapSource{"NAME": "app"}, EnvSource{}},
+		DefaultValue:  &testOrigins{Timeout: time.Second},
+	})
+	assert.Nil(t, err)
+	cfg, origins, err := loader.LoadWithOrigins()
+	assert.Nil(t, err)
+	assert.Equal(t, "hunter2", cfg.Secret)
+	assert.Equal(t, []Origin{
+		{Field: "Name", EnvName: "NAME", Source: "confik.MapSource"},
+		{Field: "Secret", EnvName: "SECRET_FILE", Source: OriginEnvironment},
+		{Field: "Timeout", EnvName: "TIMEOUT", Source: OriginDefaultValue},
+		{Field: "Optional", EnvName: "OPTIONAL", Source: OriginDefaultValue},
+	}, origins)
+}
+
+type testLayerOrigins struct {
+	Name   string
+	Level  string
+	Region string
+	Port   int
+}
+
+func TestLoaderLoadWithOriginsEnvFiles(t *testing.T) {
+	os.Clearenv()
+	cfg := testLayers("dev", "")
+	loader, err := NewLoader(Config[testLayerOrigins]{
+		UseEnvFile:     true,
+		EnvFiles:       cfg.EnvFiles,
+		EnvProfile:     "dev",
+		EnvFileOverlay: true,
+	})
+	assert.Nil(t, err)
+	_, origins, err := loader.LoadWithOrigins()
+	assert.Nil(t, err)
+	assert.Equal(t, []Origin{
+		{Field: "Name", EnvName: "NAME", Source: "env file testdata/layers/.env"},
+		{Field: "Level", EnvName: "LEVEL", Source: "env file testdata/layers/.env.local"},
+		{Field: "Region", EnvName: "REGION", Source: "env file testdata/layers/.env.dev"},
+		{Field: "Port", EnvName: "PORT", Source: "env file testdata/layers/.env.dev.local"},
+	}, origins)
+}
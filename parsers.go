@@ -1,7 +1,12 @@
 package confik
 
 import (
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"net/url"
 	"reflect"
@@ -140,21 +145,140 @@ func parseString(fc *FieldConfig, fieldValue string, rv reflect.Value) error {
 	return nil
 }
 
-func handleSlice(fc *FieldConfig, fieldValue string, rv reflect.Value) error {
-	strSlice := strings.Split(fieldValue, ",")
-	var unsliced = rv.Type().Elem()
-	converter, exists := kindParsers[unsliced.Kind()]
-	if !exists {
-		return fmt.Errorf("%s is invalid: %s is not supported", fc.Name, rv.Type())
+// elementError will return the reason an element of a slice or map could not be converted.
+func elementError(err error) error {
+	if inner := errors.Unwrap(err); inner != nil {
+		return inner
 	}
-	var data = reflect.MakeSlice(rv.Type(), 0, len(strSlice))
-	for _, v := range strSlice {
-		rv2 := reflect.New(unsliced).Elem()
-		err := converter(fc, v, rv2)
-		if err != nil {
-			return fmt.Errorf("%s=%s is not a valid %s: %w", fc.Name, fieldValue, rv.Type(), errors.Unwrap(err))
+	return err
+}
+
+// listSeparator will return the separator between the elements of a slice or the pairs of a map.
+func listSeparator(fc *FieldConfig) string {
+	if fc.Separator != nil {
+		return *fc.Separator
+	}
+	return ","
+}
+
+// splitList will split a list of elements separated by sep.
+//
+// Whitespace around each element is removed. When quoted is set, elements can be quoted ("a,b") to contain the
+// separator or surrounding whitespace, and quotes within a quoted element are escaped by doubling them
+// ("say ""hi""").
+func splitList(value string, sep string, quoted bool) ([]string, error) {
+	var elements []string
+	if !quoted {
+		for _, element := range strings.Split(value, sep) {
+			elements = append(elements, strings.TrimSpace(element))
+		}
+		return elements, nil
+	}
+	for {
+		rest := strings.TrimLeft(value, " \t")
+		if !strings.HasPrefix(rest, `"`) {
+			element, remaining, found := strings.Cut(value, sep)
+			elements = append(elements, strings.TrimSpace(element))
+			if !found {
+				return elements, nil
+			}
+			value = remaining
+			continue
+		}
+
+		// read until the closing quote (doubled quotes are escaped)
+		var sb strings.Builder
+		i := 1
+		for {
+			end := strings.IndexByte(rest[i:], '"')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated quoted element %s", rest)
+			}
+			sb.WriteString(rest[i : i+end])
+			i += end + 1
+			if i < len(rest) && rest[i] == '"' {
+				sb.WriteByte('"')
+				i++
+				continue
+			}
+			break
+		}
+		elements = append(elements, sb.String())
+
+		after := strings.TrimLeft(rest[i:], " \t")
+		if after == "" {
+			return elements, nil
+		}
+		if !strings.HasPrefix(after, sep) {
+			return nil, fmt.Errorf("unexpected characters after quoted element %s", rest[:i])
+		}
+		value = after[len(sep):]
+	}
+}
+
+// parseSlice will split a list of elements and convert each element with converter.
+//
+// rv can be a slice or an array (which requires the same number of elements as its length).
+func parseSlice(fc *FieldConfig, fieldValue string, rv reflect.Value, converter Parser) error {
+	elements, err := splitList(fieldValue, listSeparator(fc), fc.Quoted)
+	if err != nil {
+		return fmt.Errorf("%s=%s is not a valid %s: %w", fc.Name, fieldValue, rv.Type(), err)
+	}
+
+	var data reflect.Value
+	if rv.Kind() == reflect.Array {
+		if len(elements) != rv.Len() {
+			return fmt.Errorf("%s=%s is not a valid %s: expected %d elements but found %d", fc.Name, fieldValue, rv.Type(), rv.Len(), len(elements))
+		}
+		data = reflect.New(rv.Type()).Elem()
+	} else {
+		data = reflect.MakeSlice(rv.Type(), len(elements), len(elements))
+	}
+	for i, v := range elements {
+		if err := converter(fc, v, data.Index(i)); err != nil {
+			return fmt.Errorf("%s=%s is not a valid %s: %w", fc.Name, fieldValue, rv.Type(), elementError(err))
 		}
-		data = reflect.Append(data, rv2)
+	}
+	rv.Set(data)
+	return nil
+}
+
+// parseMap will split a list of key/value pairs and convert each key and value with keyConverter and valueConverter.
+//
+// The pairs are separated by "," and the key and value by ":" unless specified in the tag. Pairs can be quoted
+// like the elements of a slice when the tag has the quoted flag.
+func parseMap(fc *FieldConfig, fieldValue string, rv reflect.Value, keyConverter Parser, valueConverter Parser) error {
+	kvsep := ":"
+	if fc.KeyValueSeparator != nil {
+		kvsep = *fc.KeyValueSeparator
+	}
+
+	var data = reflect.MakeMap(rv.Type())
+	if fieldValue == "" {
+		rv.Set(data)
+		return nil
+	}
+	pairs, err := splitList(fieldValue, listSeparator(fc), fc.Quoted)
+	if err != nil {
+		return fmt.Errorf("%s=%s is not a valid %s: %w", fc.Name, fieldValue, rv.Type(), err)
+	}
+	for _, pair := range pairs {
+		k, v, found := strings.Cut(pair, kvsep)
+		if !found {
+			return fmt.Errorf("%s=%s is not a valid %s: %s is not a key%svalue pair", fc.Name, fieldValue, rv.Type(), pair, kvsep)
+		}
+		key := reflect.New(rv.Type().Key()).Elem()
+		if err := keyConverter(fc, k, key); err != nil {
+			return fmt.Errorf("%s=%s is not a valid %s: %w", fc.Name, fieldValue, rv.Type(), elementError(err))
+		}
+		if data.MapIndex(key).IsValid() {
+			return fmt.Errorf("%s=%s is not a valid %s: duplicate key %s", fc.Name, fieldValue, rv.Type(), k)
+		}
+		value := reflect.New(rv.Type().Elem()).Elem()
+		if err := valueConverter(fc, v, value); err != nil {
+			return fmt.Errorf("%s=%s is not a valid %s: %w", fc.Name, fieldValue, rv.Type(), elementError(err))
+		}
+		data.SetMapIndex(key, value)
 	}
 	rv.Set(data)
 	return nil
@@ -187,6 +311,77 @@ func parseDuration(fc *FieldConfig, fieldValue string, rv reflect.Value) error {
 	return nil
 }
 
+func parseTextUnmarshaler(fc *FieldConfig, fieldValue string, rv reflect.Value) error {
+	u := rv.Addr().Interface().(encoding.TextUnmarshaler)
+	if err := u.UnmarshalText([]byte(fieldValue)); err != nil {
+		return fmt.Errorf("%s=%s is not a valid %s: %w", fc.Name, fieldValue, rv.Type(), err)
+	}
+	return nil
+}
+
+func parseBinaryUnmarshaler(fc *FieldConfig, fieldValue string, rv reflect.Value) error {
+	u := rv.Addr().Interface().(encoding.BinaryUnmarshaler)
+	if err := u.UnmarshalBinary([]byte(fieldValue)); err != nil {
+		return fmt.Errorf("%s=%s is not a valid %s: %w", fc.Name, fieldValue, rv.Type(), err)
+	}
+	return nil
+}
+
+func parseFlagValue(fc *FieldConfig, fieldValue string, rv reflect.Value) error {
+	v := rv.Addr().Interface().(flag.Value)
+	if err := v.Set(fieldValue); err != nil {
+		return fmt.Errorf("%s=%s is not a valid %s: %w", fc.Name, fieldValue, rv.Type(), err)
+	}
+	return nil
+}
+
+func parseJSON(fc *FieldConfig, fieldValue string, rv reflect.Value) error {
+	// decode into a new value so a partial decode does not modify the field
+	ptr := reflect.New(rv.Type())
+	if err := json.Unmarshal([]byte(fieldValue), ptr.Interface()); err != nil {
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &syntaxErr) {
+			return fmt.Errorf("%s is not valid json at offset %d: %w", fc.Name, syntaxErr.Offset, err)
+		} else if errors.As(err, &typeErr) {
+			return fmt.Errorf("%s is not valid json at offset %d: %w", fc.Name, typeErr.Offset, err)
+		}
+		return fmt.Errorf("%s is not valid json: %w", fc.Name, err)
+	}
+	rv.Set(ptr.Elem())
+	return nil
+}
+
+func parseBase64(fc *FieldConfig, fieldValue string, rv reflect.Value) error {
+	b, err := base64.StdEncoding.DecodeString(fieldValue)
+	if err != nil {
+		return fmt.Errorf("%s is not valid base64: %w", fc.Name, err)
+	}
+	rv.SetBytes(b)
+	return nil
+}
+
+func parseHex(fc *FieldConfig, fieldValue string, rv reflect.Value) error {
+	b, err := hex.DecodeString(fieldValue)
+	if err != nil {
+		return fmt.Errorf("%s is not valid hex: %w", fc.Name, err)
+	}
+	rv.SetBytes(b)
+	return nil
+}
+
+var (
+	textUnmarshalerType   = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+	flagValueType         = reflect.TypeOf((*flag.Value)(nil)).Elem()
+)
+
+var formatParsers = map[string]Parser{
+	"json":   parseJSON,
+	"base64": parseBase64,
+	"hex":    parseHex,
+}
+
 var typeParsers = map[reflect.Type]Parser{
 	reflect.TypeOf((*url.URL)(nil)).Elem():       parseUrl,
 	reflect.TypeOf((*time.Time)(nil)).Elem():     parseTime,
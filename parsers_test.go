@@ -1,9 +1,12 @@
 package confik
 
 import (
+	"errors"
+	"net"
 	"net/url"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -39,6 +42,112 @@ func TestLoadFromEnvCustomConverter(t *testing.T) {
 	assert.Equal(t, custom.MyField.Value, "hello")
 }
 
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+type testPoint struct {
+	X, Y byte
+}
+
+func (p *testPoint) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return errors.New("expected 2 bytes")
+	}
+	p.X, p.Y = data[0], data[1]
+	return nil
+}
+
+type testFlagList []string
+
+func (l *testFlagList) String() string {
+	return ""
+}
+
+func (l *testFlagList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+type testUnmarshalers struct {
+	Level    testLevel
+	Levels   []testLevel
+	Optional *testLevel `env:"OPTIONAL,optional"`
+	IP       net.IP
+	Host     testFlagList
+}
+
+func TestLoadFromEnvUnmarshalers(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("LEVEL", "info")
+	os.Setenv("LEVELS", "debug,info")
+	os.Setenv("OPTIONAL", "info")
+	os.Setenv("I_P", "127.0.0.1")
+	os.Setenv("HOST", "localhost")
+	cfg, err := LoadFromEnv(Config[testUnmarshalers]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, testLevel(1), cfg.Level)
+	assert.Equal(t, []testLevel{0, 1}, cfg.Levels)
+	if assert.NotNil(t, cfg.Optional) {
+		assert.Equal(t, testLevel(1), *cfg.Optional)
+	}
+	assert.Equal(t, "127.0.0.1", cfg.IP.String())
+	assert.Equal(t, testFlagList{"localhost"}, cfg.Host)
+}
+
+func TestLoadFromEnvUnmarshalerError(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("LEVEL", "trace")
+	os.Setenv("LEVELS", "debug,trace")
+	os.Setenv("I_P", "127.0.0.1")
+	os.Setenv("HOST", "localhost")
+	_, err := LoadFromEnv(Config[testUnmarshalers]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrParse)
+		expected := `2 errors occurred while loading the environment:
+  - LEVEL=trace is not a valid confik.testLevel: unknown level
+  - LEVELS=debug,trace is not a valid []confik.testLevel: unknown level`
+		assert.Equal(t, expected, err.Error())
+	}
+}
+
+type testBinaryUnmarshaler struct {
+	Point testPoint
+}
+
+func TestLoadFromEnvBinaryUnmarshaler(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("POINT", "ab")
+	cfg, err := LoadFromEnv(Config[testBinaryUnmarshaler]{
+		UseEnvFile:           false,
+		UseBinaryUnmarshaler: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, testPoint{X: 'a', Y: 'b'}, cfg.Point)
+
+	// without the opt-in the struct is loaded as a nested struct
+	_, err = LoadFromEnv(Config[testBinaryUnmarshaler]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrMissing)
+	}
+}
+
 func TestUint(t *testing.T) {
 	var res uint
 	rv := reflect.ValueOf(&res).Elem()
@@ -281,7 +390,7 @@ func TestStringSlice(t *testing.T) {
 		ConfigTag: NewConfigTag("test"),
 		Validate:  nil,
 	}
-	err := handleSlice(fc, "string1,string2", rv)
+	err := parseSlice(fc, "string1,string2", rv, parseString)
 	assert.Nil(t, err)
 	assert.Equal(t, []string{"string1", "string2"}, res)
 }
@@ -293,7 +402,7 @@ func TestIntSlice(t *testing.T) {
 		ConfigTag: NewConfigTag("test"),
 		Validate:  nil,
 	}
-	err := handleSlice(fc, "1,2", rv)
+	err := parseSlice(fc, "1,2", rv, parseInt)
 	assert.Nil(t, err)
 	assert.Equal(t, []int{1, 2}, res)
 }
@@ -305,12 +414,298 @@ func TestIntSliceFailMixed(t *testing.T) {
 		ConfigTag: NewConfigTag("test"),
 		Validate:  nil,
 	}
-	err := handleSlice(fc, "1,hh", rv)
+	err := parseSlice(fc, "1,hh", rv, parseInt)
 	if assert.Error(t, err) {
 		assert.Equal(t, "test=1,hh is not a valid []int: strconv.ParseInt: parsing \"hh\": invalid syntax", err.Error())
 	}
 }
 
+func TestSplitList(t *testing.T) {
+	elements, err := splitList(" a , b,c ", ",", true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, elements)
+
+	elements, err = splitList(` "a,b" ,c`, ",", false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`"a`, `b"`, "c"}, elements)
+
+	elements, err = splitList(`"a,b", " c ","say ""hi"""`, ",", true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a,b", " c ", `say "hi"`}, elements)
+
+	elements, err = splitList("a;;b", ";", true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "", "b"}, elements)
+
+	_, err = splitList(`a,"b`, ",", true)
+	if assert.Error(t, err) {
+		assert.Equal(t, `unterminated quoted element "b`, err.Error())
+	}
+
+	_, err = splitList(`"a"b,c`, ",", true)
+	if assert.Error(t, err) {
+		assert.Equal(t, `unexpected characters after quoted element "a"`, err.Error())
+	}
+}
+
+func TestArray(t *testing.T) {
+	var res [3]int
+	rv := reflect.ValueOf(&res).Elem()
+	fc := &FieldConfig{
+		ConfigTag: NewConfigTag("test"),
+		Validate:  nil,
+	}
+	err := parseSlice(fc, "1,2,3", rv, parseInt)
+	assert.Nil(t, err)
+	assert.Equal(t, [3]int{1, 2, 3}, res)
+
+	err = parseSlice(fc, "1,2", rv, parseInt)
+	if assert.Error(t, err) {
+		assert.Equal(t, "test=1,2 is not a valid [3]int: expected 3 elements but found 2", err.Error())
+	}
+}
+
+type testSlices struct {
+	Timeouts []time.Duration
+	Websites []url.URL
+	IPs      []net.IP `env:"IPS"`
+	Custom   []MyCustomType
+	Words    []string `env:"WORDS,sep=;"`
+	Quoted   []string `env:"QUOTED,quoted"`
+	Point    [2]float64
+}
+
+func TestLoadFromEnvSlices(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("TIMEOUTS", "1s, 2m")
+	os.Setenv("WEBSITES", "https://a.com,https://b.com")
+	os.Setenv("IPS", "127.0.0.1,::1")
+	os.Setenv("CUSTOM", "a,b")
+	os.Setenv("WORDS", "hello, world;bye")
+	os.Setenv("QUOTED", `"a,b",c`)
+	os.Setenv("POINT", "1.5,2")
+	cfg, err := LoadFromEnv(Config[testSlices]{
+		UseEnvFile: false,
+		Parsers: map[reflect.Type]Parser{
+			reflect.TypeOf(MyCustomType{}): parseMyCustomType,
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Minute}, cfg.Timeouts)
+	assert.Equal(t, "b.com", cfg.Websites[1].Host)
+	assert.Equal(t, "::1", cfg.IPs[1].String())
+	assert.Equal(t, []MyCustomType{{Value: "a"}, {Value: "b"}}, cfg.Custom)
+	assert.Equal(t, []string{"hello, world", "bye"}, cfg.Words)
+	assert.Equal(t, []string{"a,b", "c"}, cfg.Quoted)
+	assert.Equal(t, [2]float64{1.5, 2}, cfg.Point)
+}
+
+func TestLoadFromEnvArrayLength(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("TIMEOUTS", "1s")
+	os.Setenv("WEBSITES", "https://a.com")
+	os.Setenv("IPS", "127.0.0.1")
+	os.Setenv("CUSTOM", "a")
+	os.Setenv("WORDS", "a")
+	os.Setenv("QUOTED", "a")
+	os.Setenv("POINT", "1,2,3")
+	_, err := LoadFromEnv(Config[testSlices]{
+		UseEnvFile: false,
+		Parsers: map[reflect.Type]Parser{
+			reflect.TypeOf(MyCustomType{}): parseMyCustomType,
+		},
+	})
+	if assert.Error(t, err) {
+		assert.Equal(t, "POINT=1,2,3 is not a valid [2]float64: expected 2 elements but found 3", err.Error())
+	}
+}
+
+func TestMap(t *testing.T) {
+	var res map[string]int
+	rv := reflect.ValueOf(&res).Elem()
+	fc := &FieldConfig{
+		ConfigTag: NewConfigTag("test"),
+		Validate:  nil,
+	}
+	err := parseMap(fc, "api:100,web:50", rv, parseString, parseInt)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"api": 100, "web": 50}, res)
+
+	err = parseMap(fc, "", rv, parseString, parseInt)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{}, res)
+}
+
+func TestMapSeparators(t *testing.T) {
+	var res map[string]string
+	rv := reflect.ValueOf(&res).Elem()
+	sep, kvsep := ";", "="
+	fc := &FieldConfig{
+		ConfigTag: NewConfigTag("test"),
+		Validate:  nil,
+	}
+	fc.Separator = &sep
+	fc.KeyValueSeparator = &kvsep
+	err := parseMap(fc, "a=1,2;b=3", rv, parseString, parseString)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"a": "1,2", "b": "3"}, res)
+}
+
+func TestMapFail(t *testing.T) {
+	var res map[string]int
+	rv := reflect.ValueOf(&res).Elem()
+	fc := &FieldConfig{
+		ConfigTag: NewConfigTag("test"),
+		Validate:  nil,
+	}
+	err := parseMap(fc, "api:100,web", rv, parseString, parseInt)
+	if assert.Error(t, err) {
+		assert.Equal(t, "test=api:100,web is not a valid map[string]int: web is not a key:value pair", err.Error())
+	}
+	err = parseMap(fc, "api:100,api:50", rv, parseString, parseInt)
+	if assert.Error(t, err) {
+		assert.Equal(t, "test=api:100,api:50 is not a valid map[string]int: duplicate key api", err.Error())
+	}
+	err = parseMap(fc, "api:hh", rv, parseString, parseInt)
+	if assert.Error(t, err) {
+		assert.Equal(t, "test=api:hh is not a valid map[string]int: strconv.ParseInt: parsing \"hh\": invalid syntax", err.Error())
+	}
+}
+
+type testMaps struct {
+	Limits   map[string]int
+	Timeouts map[string]time.Duration `env:"TIMEOUTS,sep=;,kvsep=/"`
+	Ports    map[uint16]bool          `env:"PORTS,optional"`
+	Levels   map[string]testLevel
+	Empty    map[string]string
+}
+
+func TestLoadFromEnvMaps(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("LIMITS", "api:100,web:50")
+	os.Setenv("TIMEOUTS", "read/5s;write/10s")
+	os.Setenv("LEVELS", "app:debug,db:info")
+	os.Setenv("EMPTY", "")
+	cfg, err := LoadFromEnv(Config[testMaps]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"api": 100, "web": 50}, cfg.Limits)
+	assert.Equal(t, map[string]time.Duration{"read": 5 * time.Second, "write": 10 * time.Second}, cfg.Timeouts)
+	assert.Nil(t, cfg.Ports)
+	assert.Equal(t, map[string]testLevel{"app": 0, "db": 1}, cfg.Levels)
+	assert.Equal(t, map[string]string{}, cfg.Empty)
+}
+
+func TestJSON(t *testing.T) {
+	var res map[string]int
+	rv := reflect.ValueOf(&res).Elem()
+	fc := &FieldConfig{
+		ConfigTag: NewConfigTag("test"),
+		Validate:  nil,
+	}
+	err := parseJSON(fc, `{"a": 1}`, rv)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"a": 1}, res)
+
+	err = parseJSON(fc, `{"a": 1,}`, rv)
+	if assert.Error(t, err) {
+		assert.Equal(t, "test is not valid json at offset 9: invalid character '}' looking for beginning of object key string", err.Error())
+	}
+	err = parseJSON(fc, `{"b": "x"}`, rv)
+	if assert.Error(t, err) {
+		assert.True(t, strings.HasPrefix(err.Error(), "test is not valid json at offset 9: json: cannot unmarshal string"))
+	}
+	assert.Equal(t, map[string]int{"a": 1}, res)
+}
+
+func TestBase64(t *testing.T) {
+	var res []byte
+	rv := reflect.ValueOf(&res).Elem()
+	fc := &FieldConfig{
+		ConfigTag: NewConfigTag("test"),
+		Validate:  nil,
+	}
+	err := parseBase64(fc, "aGVsbG8=", rv)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hello"), res)
+
+	err = parseBase64(fc, "aGVsbG8", rv)
+	if assert.Error(t, err) {
+		assert.Equal(t, "test is not valid base64: illegal base64 data at input byte 4", err.Error())
+	}
+}
+
+func TestHex(t *testing.T) {
+	var res []byte
+	rv := reflect.ValueOf(&res).Elem()
+	fc := &FieldConfig{
+		ConfigTag: NewConfigTag("test"),
+		Validate:  nil,
+	}
+	err := parseHex(fc, "cafe", rv)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0xca, 0xfe}, res)
+
+	err = parseHex(fc, "xyz", rv)
+	if assert.Error(t, err) {
+		assert.Equal(t, "test is not valid hex: encoding/hex: invalid byte: U+0078 'x'", err.Error())
+	}
+}
+
+type testFormats struct {
+	Server struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	} `env:"SERVER,format=json"`
+	Limits  map[string]int `env:"LIMITS,format=json"`
+	Hosts   []string       `env:"HOSTS,format=json"`
+	Options *struct {
+		Debug bool `json:"debug"`
+	} `env:"OPTIONS,format=json"`
+	Key    []byte  `env:"KEY,format=base64"`
+	Secret *[]byte `env:"SECRET,format=hex"`
+}
+
+func TestLoadFromEnvFormats(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("SERVER", `{"host": "localhost", "port": 8080}`)
+	os.Setenv("LIMITS", `{"api": 100}`)
+	os.Setenv("HOSTS", `["a.com", "b.com"]`)
+	os.Setenv("OPTIONS", `{"debug": true}`)
+	os.Setenv("KEY", "aGVsbG8=")
+	os.Setenv("SECRET", "cafe")
+	cfg, err := LoadFromEnv(Config[testFormats]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost", cfg.Server.Host)
+	assert.Equal(t, 8080, cfg.Server.Port)
+	assert.Equal(t, map[string]int{"api": 100}, cfg.Limits)
+	assert.Equal(t, []string{"a.com", "b.com"}, cfg.Hosts)
+	if assert.NotNil(t, cfg.Options) {
+		assert.True(t, cfg.Options.Debug)
+	}
+	assert.Equal(t, []byte("hello"), cfg.Key)
+	if assert.NotNil(t, cfg.Secret) {
+		assert.Equal(t, []byte{0xca, 0xfe}, *cfg.Secret)
+	}
+}
+
+type testInvalidFormat struct {
+	Key string `env:"KEY,format=base64"`
+}
+
+func TestNewLoaderInvalidFormat(t *testing.T) {
+	_, err := NewLoader(Config[testInvalidFormat]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrInvalidTag)
+		assert.Equal(t, "invalid tag on field Key: format base64 requires a []byte but found string", err.Error())
+	}
+}
+
 func TestUrl(t *testing.T) {
 	var res url.URL
 	rv := reflect.ValueOf(&res).Elem()
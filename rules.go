@@ -0,0 +1,164 @@
+package confik
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// rule is a requirement between the fields of a struct (like required_if=TLS_ENABLED:true).
+//
+// The rules are checked once every field of the struct rv is loaded. set reports which fields of the plan have a
+// value (from a source or a default) and the prefix and path are prepended to the names and paths of the fields.
+type rule func(rv reflect.Value, set []bool, prefix string, path string, errs *LoadError)
+
+// compileRules will compile the conditional requirements on the fields of the plan.
+//
+// The names in the rules refer to the variables of other fields in the same struct (without the prefix).
+func (c *compiler) compileRules(plan *structPlan, prefix string, errs *LoadError) {
+	find := func(name string) int {
+		for i, fp := range plan.fields {
+			if fp.nested == nil && fp.Name == prefix+name {
+				return i
+			}
+		}
+		return -1
+	}
+
+	groups := make(map[string][]int)
+	var groupNames []string
+	for i, fp := range plan.fields {
+		if !fp.isConditional() {
+			continue
+		}
+		errPath := joinFieldPath(c.scope, fp.Field)
+		invalid := func(err error) {
+			errs.add(errPath, fp.Name, "", ErrInvalidTag, fmt.Errorf("invalid tag on field %s: %w", errPath, err))
+		}
+		if fp.nested != nil {
+			invalid(fmt.Errorf("required_if, required_with and oneof_group are not supported for nested structs"))
+			continue
+		}
+
+		if fp.RequiredIf != nil {
+			name, value, _ := strings.Cut(*fp.RequiredIf, ":")
+			j := find(name)
+			if j == -1 {
+				invalid(fmt.Errorf("required_if refers to unknown field %s", name))
+				continue
+			}
+			target := plan.fields[j]
+			if target.parser == nil {
+				invalid(fmt.Errorf("required_if is not supported for %s", target.typ))
+				continue
+			}
+			expected := reflect.New(target.typ).Elem()
+			if err := target.parser(target.FieldConfig, value, expected); err != nil {
+				invalid(fmt.Errorf("invalid required_if value %s: %w", value, err))
+				continue
+			}
+			plan.rules = append(plan.rules, requiredIf(i, fp, j, target, value, expected))
+		}
+
+		if fp.RequiredWith != nil {
+			var targets []int
+			for _, name := range fp.RequiredWith {
+				j := find(name)
+				if j == -1 {
+					invalid(fmt.Errorf("required_with refers to unknown field %s", name))
+					break
+				}
+				targets = append(targets, j)
+			}
+			if len(targets) != len(fp.RequiredWith) {
+				continue
+			}
+			plan.rules = append(plan.rules, requiredWith(i, fp, targets, plan.fields))
+		}
+
+		if fp.OneOfGroup != nil {
+			group := *fp.OneOfGroup
+			if _, exists := groups[group]; !exists {
+				groupNames = append(groupNames, group)
+			}
+			groups[group] = append(groups[group], i)
+		}
+	}
+
+	for _, group := range groupNames {
+		members := groups[group]
+		if len(members) < 2 {
+			fp := plan.fields[members[0]]
+			errPath := joinFieldPath(c.scope, fp.Field)
+			err := fmt.Errorf("invalid tag on field %s: oneof_group %s must have more than one field", errPath, group)
+			errs.add(errPath, fp.Name, "", ErrInvalidTag, err)
+			continue
+		}
+		plan.rules = append(plan.rules, oneOfGroup(members, plan.fields))
+	}
+}
+
+// requiredIf will create a [rule] that requires the field i when the field j is set to the expected value.
+func requiredIf(i int, fp *fieldPlan, j int, target *fieldPlan, value string, expected reflect.Value) rule {
+	for expected.Kind() == reflect.Pointer {
+		expected = expected.Elem()
+	}
+	return func(rv reflect.Value, set []bool, prefix string, path string, errs *LoadError) {
+		if set[i] || !set[j] {
+			return
+		}
+		actual := rv.Field(target.index)
+		for actual.Kind() == reflect.Pointer {
+			if actual.IsNil() {
+				return
+			}
+			actual = actual.Elem()
+		}
+		if !reflect.DeepEqual(actual.Interface(), expected.Interface()) {
+			return
+		}
+		err := fmt.Errorf("environment variable %s is required when %s=%s", prefix+fp.Name, prefix+target.Name, value)
+		errs.add(joinFieldPath(path, fp.Field), prefix+fp.Name, "", ErrMissing, err)
+	}
+}
+
+// requiredWith will create a [rule] that requires the field i when any of the target fields are set.
+func requiredWith(i int, fp *fieldPlan, targets []int, fields []*fieldPlan) rule {
+	return func(rv reflect.Value, set []bool, prefix string, path string, errs *LoadError) {
+		if set[i] {
+			return
+		}
+		for _, j := range targets {
+			if set[j] {
+				err := fmt.Errorf("environment variable %s is required with %s", prefix+fp.Name, prefix+fields[j].Name)
+				errs.add(joinFieldPath(path, fp.Field), prefix+fp.Name, "", ErrMissing, err)
+				return
+			}
+		}
+	}
+}
+
+// oneOfGroup will create a [rule] that requires exactly one of the members to be set.
+//
+// The errors are reported on the first member of the group.
+func oneOfGroup(members []int, fields []*fieldPlan) rule {
+	return func(rv reflect.Value, set []bool, prefix string, path string, errs *LoadError) {
+		names := make([]string, len(members))
+		var found []string
+		for k, i := range members {
+			names[k] = prefix + fields[i].Name
+			if set[i] {
+				found = append(found, names[k])
+			}
+		}
+		first := fields[members[0]]
+		switch {
+		case len(found) == 0:
+			err := fmt.Errorf("one of environment variables %s must be set", strings.Join(names, ", "))
+			errs.add(joinFieldPath(path, first.Field), names[0], "", ErrMissing, err)
+		case len(found) > 1:
+			err := fmt.Errorf("only one of environment variables %s can be set but found %s", strings.Join(names, ", "), strings.Join(found, ", "))
+			errs.add(joinFieldPath(path, first.Field), names[0], "", ErrValidation, err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package confik
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testRules struct {
+	TLSEnabled bool   `env:"TLS_ENABLED,default=false"`
+	TLSCert    string `env:"TLS_CERT,required_if=TLS_ENABLED:true"`
+	TLSKey     string `env:"TLS_KEY,required_with=TLS_CERT"`
+	APIKey     string `env:"API_KEY,oneof_group=auth"`
+	OAuthToken string `env:"OAUTH_TOKEN,oneof_group=auth"`
+}
+
+func TestLoadFromEnvRules(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("API_KEY", "secret")
+	cfg, err := LoadFromEnv(Config[testRules]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "secret", cfg.APIKey)
+	assert.Equal(t, "", cfg.TLSCert)
+
+	os.Setenv("TLS_ENABLED", "1")
+	os.Setenv("TLS_CERT", "cert.pem")
+	os.Setenv("TLS_KEY", "key.pem")
+	cfg, err = LoadFromEnv(Config[testRules]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "cert.pem", cfg.TLSCert)
+}
+
+func TestLoadFromEnvRuleErrors(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("TLS_ENABLED", "true")
+	_, err := LoadFromEnv(Config[testRules]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrMissing)
+		assert.Equal(t, []string{
+			"environment variable TLS_CERT is required when TLS_ENABLED=true",
+			"one of environment variables API_KEY, OAUTH_TOKEN must be set",
+		}, loadErrorMessages(t, err))
+		var loadErr *LoadError
+		if assert.ErrorAs(t, err, &loadErr) && assert.Equal(t, 2, len(loadErr.Errors)) {
+			assert.Equal(t, "TLSCert", loadErr.Errors[0].Field)
+			assert.Equal(t, "APIKey", loadErr.Errors[1].Field)
+		}
+	}
+
+	os.Setenv("TLS_CERT", "cert.pem")
+	os.Setenv("API_KEY", "secret")
+	os.Setenv("OAUTH_TOKEN", "token")
+	_, err = LoadFromEnv(Config[testRules]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		var loadErr *LoadError
+		if assert.ErrorAs(t, err, &loadErr) && assert.Equal(t, 2, len(loadErr.Errors)) {
+			assert.Equal(t, "environment variable TLS_KEY is required with TLS_CERT", loadErr.Errors[0].Error())
+			assert.ErrorIs(t, loadErr.Errors[0], ErrMissing)
+			assert.Equal(t, "only one of environment variables API_KEY, OAUTH_TOKEN can be set but found API_KEY, OAUTH_TOKEN", loadErr.Errors[1].Error())
+			assert.ErrorIs(t, loadErr.Errors[1], ErrValidation)
+		}
+	}
+}
+
+type testRulesServer struct {
+	Host string `env:"HOST,optional"`
+	Port uint16 `env:"PORT,required_with=HOST"`
+}
+
+func TestLoadFromEnvRulesNested(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("PRIMARY_HOST", "a.com")
+	os.Setenv("SERVERS_0_HOST", "b.com")
+	_, err := LoadFromEnv(Config[struct {
+		Primary testRulesServer
+		Servers []testRulesServer
+	}]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		var loadErr *LoadError
+		if assert.ErrorAs(t, err, &loadErr) && assert.Equal(t, 2, len(loadErr.Errors)) {
+			assert.Equal(t, "Primary.Port", loadErr.Errors[0].Field)
+			assert.Equal(t, "environment variable PRIMARY_PORT is required with PRIMARY_HOST", loadErr.Errors[0].Error())
+			assert.Equal(t, "Servers[0].Port", loadErr.Errors[1].Field)
+			assert.Equal(t, "environment variable SERVERS_0_PORT is required with SERVERS_0_HOST", loadErr.Errors[1].Error())
+		}
+	}
+}
+
+type testInvalidRules struct {
+	Cert     string                `env:"CERT,required_if=ENABLED:true"`
+	Key      string                `env:"KEY,required_with=CERT|NOPE"`
+	Port     uint16                `env:"PORT,required_if=CERT_PORT:abc"`
+	CertPort uint16                `env:"CERT_PORT,optional"`
+	Token    string                `env:"TOKEN,oneof_group=auth"`
+	Database struct{ Host string } `env:"DATABASE,required_with=CERT"`
+}
+
+func TestNewLoaderInvalidRules(t *testing.T) {
+	_, err := NewLoader(Config[testInvalidRules]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrInvalidTag)
+		assert.Equal(t, []string{
+			"invalid tag on field Cert: required_if refers to unknown field ENABLED",
+			"invalid tag on field Key: required_with refers to unknown field NOPE",
+			`invalid tag on field Port: invalid required_if value abc: CERT_PORT=abc is not a valid uint16: strconv.ParseUint: parsing "abc": invalid syntax`,
+			"invalid tag on field Database: required_if, required_with and oneof_group are not supported for nested structs",
+			"invalid tag on field Token: oneof_group auth must have more than one field",
+		}, loadErrorMessages(t, err))
+	}
+}
@@ -0,0 +1,283 @@
+package confik
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Source is the interface a provider of environment values must implement.
+//
+// Sources are configured in [Config] as an ordered list where the first source containing a key wins.
+type Source interface {
+	Lookup(key string) (string, bool) // get the value of a key (and whether it exists)
+	Keys() []string                   // list all the keys in the source
+}
+
+// Origin is where the value of a field was loaded from (see [Loader.LoadWithOrigins]).
+type Origin struct {
+	Field   string // the dotted path to the field within the struct
+	EnvName string // the name of the variable that set the field
+	Source  string // the source of the value (like "environment", "json file config.json" or "default tag")
+}
+
+// The sources of an [Origin] that are not described by a [Source].
+const (
+	OriginEnvironment  = "environment"   // the process environment
+	OriginDefaultValue = "default value" // the DefaultValue in [Config]
+	OriginDefaultTag   = "default tag"   // the default setting in the tag of the field
+)
+
+// EnvSource is a [Source] that reads from the process environment.
+type EnvSource struct{}
+
+// Lookup will get the value of the environment variable named by the key.
+func (EnvSource) Lookup(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+// String will describe the source.
+func (EnvSource) String() string {
+	return OriginEnvironment
+}
+
+// Keys will list the names of all the environment variables.
+func (EnvSource) Keys() []string {
+	environ := os.Environ()
+	keys := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		key, _, _ := strings.Cut(kv, "=")
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MapSource is a [Source] backed by an in-memory map.
+type MapSource map[string]string
+
+// Lookup will get the value of the key from the map.
+func (m MapSource) Lookup(key string) (string, bool) {
+	value, exists := m[key]
+	return value, exists
+}
+
+// Keys will list all the keys in the map.
+func (m MapSource) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// EnvFileSource is a [Source] backed by a parsed environment file.
+//
+// The file is read once when the source is created and the process environment is never modified. References to
+// other variables in the values are not expanded.
+type EnvFileSource struct {
+	MapSource        // the values parsed from the file
+	Path      string // path to the environment file
+}
+
+// NewEnvFileSource will create a new [EnvFileSource] by parsing the environment file at path.
+func NewEnvFileSource(path string) (*EnvFileSource, error) {
+	kv, err := readEnvFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &EnvFileSource{
+		MapSource: envValuesToMap(kv),
+		Path:      path,
+	}, nil
+}
+
+// String will describe the source.
+func (s *EnvFileSource) String() string {
+	return "env file " + s.Path
+}
+
+// lookupSources will get the value of the key from the first source that contains it.
+func lookupSources(sources []Source, key string) (string, bool) {
+	value, _, exists := findSource(sources, key)
+	return value, exists
+}
+
+// findSource will get the value of the key and the first source that contains it.
+func findSource(sources []Source, key string) (string, Source, bool) {
+	for _, source := range sources {
+		if value, exists := source.Lookup(key); exists {
+			return value, source, true
+		}
+	}
+	return "", nil, false
+}
+
+// sourceName will describe a source for an [Origin].
+//
+// Sources that implement [fmt.Stringer] describe themselves (otherwise the type of the source is used).
+func sourceName(source Source) string {
+	if stringer, ok := source.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", source)
+}
+
+// dirDataLink is the symlink Kubernetes updates atomically when a projected volume changes.
+const dirDataLink = "..data"
+
+// DirSource is a [Source] that reads values from the files in a directory, like a Kubernetes ConfigMap or Secret
+// mounted as a volume.
+//
+// Each file name is a key and the contents of the file are the value (without a single trailing newline). Keys are
+// converted to environment variable names by converting them to uppercase and replacing "-" and "." with "_". The
+// "..data" symlinks (and any other entries starting with "..") and directories are ignored. Lookups return the
+// values of the last read of the directory (see [DirSource.Reload] and AutoReload).
+type DirSource struct {
+	Path       string // path to the directory
+	AutoReload bool   // re-read the directory at the start of a load when the "..data" symlink changed?
+
+	mu         sync.RWMutex
+	values     map[string]string
+	dataTarget string
+}
+
+// NewDirSource will create a new [DirSource] by reading the files in the directory at path.
+func NewDirSource(path string, autoReload bool) (*DirSource, error) {
+	source := &DirSource{
+		Path:       path,
+		AutoReload: autoReload,
+	}
+	if err := source.Reload(); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// dirKeyToEnvName will convert the name of a file in a [DirSource] to an environment variable name.
+func dirKeyToEnvName(key string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+}
+
+// Reload will re-read all of the files in the directory.
+//
+// The previous values are kept if the directory cannot be read.
+func (s *DirSource) Reload() error {
+	stat, err := os.Stat(s.Path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("directory does not exist: %s", s.Path)
+		}
+		return err
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("directory source is not a directory: %s", s.Path)
+	}
+
+	// read the target first so a change during the reload is detected next time
+	dataTarget, _ := os.Readlink(filepath.Join(s.Path, dirDataLink))
+	entries, err := os.ReadDir(s.Path)
+	if err != nil {
+		return err
+	}
+	values := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "..") {
+			continue
+		}
+		// follow symlinks to determine if the entry is a file
+		path := filepath.Join(s.Path, entry.Name())
+		stat, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if stat.IsDir() {
+			continue
+		}
+		envName := dirKeyToEnvName(entry.Name())
+		value, err := readValueFile(envName, path)
+		if err != nil {
+			return err
+		}
+		values[envName] = value
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = values
+	s.dataTarget = dataTarget
+	return nil
+}
+
+// snapshot will reload the directory if [DirSource.AutoReload] is set and the "..data" symlink changed and return
+// the current values as a [Source] that does not change during a load.
+func (s *DirSource) snapshot() Source {
+	if s.AutoReload {
+		dataTarget, err := os.Readlink(filepath.Join(s.Path, dirDataLink))
+		s.mu.RLock()
+		changed := err == nil && dataTarget != s.dataTarget
+		s.mu.RUnlock()
+		if changed {
+			// keep the previous values if the reload fails
+			_ = s.Reload()
+		}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return &dirSnapshot{MapSource: s.values, path: s.Path}
+}
+
+// String will describe the source.
+func (s *DirSource) String() string {
+	return "directory " + s.Path
+}
+
+// Lookup will get the value of the file for the key.
+func (s *DirSource) Lookup(key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, exists := s.values[key]
+	return value, exists
+}
+
+// Keys will list the keys for all the files in the directory.
+func (s *DirSource) Keys() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return MapSource(s.values).Keys()
+}
+
+// dirSnapshot is the values of a [DirSource] at the start of a load.
+//
+// The values are never modified as [DirSource.Reload] replaces the map.
+type dirSnapshot struct {
+	MapSource
+	path string
+}
+
+// String will describe the source.
+func (s *dirSnapshot) String() string {
+	return "directory " + s.path
+}
+
+// snapshotSource is implemented by the sources that can change while loading (like a [DirSource]).
+type snapshotSource interface {
+	snapshot() Source // get the current values as a source that does not change
+}
+
+// snapshotSources will replace the sources that can change while loading with a snapshot of their values so a load
+// reads a consistent view of every source.
+func snapshotSources(sources []Source) []Source {
+	snapshots := make([]Source, len(sources))
+	for i, source := range sources {
+		if s, ok := source.(snapshotSource); ok {
+			source = s.snapshot()
+		}
+		snapshots[i] = source
+	}
+	return snapshots
+}
@@ -0,0 +1,202 @@
+package confik
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvSource(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("B", "2")
+	os.Setenv("A", "1")
+	source := EnvSource{}
+	value, exists := source.Lookup("A")
+	assert.True(t, exists)
+	assert.Equal(t, "1", value)
+	_, exists = source.Lookup("C")
+	assert.False(t, exists)
+	assert.Equal(t, []string{"A", "B"}, source.Keys())
+}
+
+func TestMapSource(t *testing.T) {
+	source := MapSource{"B": "2", "A": "1"}
+	value, exists := source.Lookup("B")
+	assert.True(t, exists)
+	assert.Equal(t, "2", value)
+	_, exists = source.Lookup("C")
+	assert.False(t, exists)
+	assert.Equal(t, []string{"A", "B"}, source.Keys())
+}
+
+func TestEnvFileSource(t *testing.T) {
+	os.Clearenv()
+	source, err := NewEnvFileSource("testdata/.uri")
+	assert.Nil(t, err)
+	assert.Equal(t, "testdata/.uri", source.Path)
+	value, exists := source.Lookup("WEBSITE")
+	assert.True(t, exists)
+	assert.Equal(t, "https://google.com/my_site", value)
+	assert.Equal(t, []string{"WEBSITE"}, source.Keys())
+	_, exists = os.LookupEnv("WEBSITE")
+	assert.False(t, exists)
+
+	_, err = NewEnvFileSource(".fake")
+	if assert.Error(t, err) {
+		assert.Equal(t, "environment file does not exist: .fake", err.Error())
+	}
+}
+
+func TestLookupSources(t *testing.T) {
+	sources := []Source{
+		MapSource{"A": "first"},
+		MapSource{"A": "second", "B": "second"},
+	}
+	value, exists := lookupSources(sources, "A")
+	assert.True(t, exists)
+	assert.Equal(t, "first", value)
+	value, exists = lookupSources(sources, "B")
+	assert.True(t, exists)
+	assert.Equal(t, "second", value)
+	_, exists = lookupSources(sources, "C")
+	assert.False(t, exists)
+}
+
+type testSources struct {
+	Name string
+	Age  uint8
+}
+
+func TestLoadFromEnvSources(t *testing.T) {
+	t.Parallel()
+	cfg, err := LoadFromEnv(Config[testSources]{
+		UseEnvFile: false,
+		Sources: []Source{
+			MapSource{"NAME": "Bob"},
+			MapSource{"NAME": "Alice", "AGE": "20"},
+		},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "Bob", cfg.Name)
+	assert.Equal(t, uint8(20), cfg.Age)
+}
+
+// writeProjectedVolume will write the files into a new timestamped directory within dir and point the "..data"
+// symlink (and the symlink for each file) to it like Kubernetes does for projected volumes.
+func writeProjectedVolume(t *testing.T, dir string, version string, files map[string]string) {
+	versionDir := filepath.Join(dir, ".."+version)
+	assert.Nil(t, os.Mkdir(versionDir, 0o755))
+	for name, contents := range files {
+		assert.Nil(t, os.WriteFile(filepath.Join(versionDir, name), []byte(contents), 0o644))
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			assert.Nil(t, os.Symlink(filepath.Join(dirDataLink, name), link))
+		}
+	}
+	tmpLink := filepath.Join(dir, "..data_tmp")
+	assert.Nil(t, os.Symlink(".."+version, tmpLink))
+	assert.Nil(t, os.Rename(tmpLink, filepath.Join(dir, dirDataLink)))
+}
+
+func TestDirSource(t *testing.T) {
+	dir := t.TempDir()
+	writeProjectedVolume(t, dir, "2024_01", map[string]string{
+		"db-password": "hunter2\n",
+		"log.level":   "debug",
+	})
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "nested"), 0o755))
+
+	source, err := NewDirSource(dir, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"DB_PASSWORD", "LOG_LEVEL"}, source.Keys())
+	value, exists := source.Lookup("DB_PASSWORD")
+	assert.True(t, exists)
+	assert.Equal(t, "hunter2", value)
+	_, exists = source.Lookup("NESTED")
+	assert.False(t, exists)
+
+	// changes are ignored without auto reload
+	writeProjectedVolume(t, dir, "2024_02", map[string]string{
+		"db-password": "changed",
+		"log.level":   "info",
+	})
+	value, _ = source.Lookup("LOG_LEVEL")
+	assert.Equal(t, "debug", value)
+
+	assert.Nil(t, source.Reload())
+	value, _ = source.Lookup("LOG_LEVEL")
+	assert.Equal(t, "info", value)
+}
+
+func TestDirSourceAutoReload(t *testing.T) {
+	dir := t.TempDir()
+	writeProjectedVolume(t, dir, "2024_01", map[string]string{
+		"LOG_LEVEL": "debug",
+	})
+	source, err := NewDirSource(dir, true)
+	assert.Nil(t, err)
+	loader, err := NewLoader(Config[testDirReload]{
+		UseEnvFile: false,
+		Sources:    []Source{source},
+	})
+	assert.Nil(t, err)
+	cfg, origins, err := loader.LoadWithOrigins()
+	assert.Nil(t, err)
+	assert.Equal(t, "debug", cfg.LogLevel)
+	assert.Equal(t, "directory "+dir, origins[0].Source)
+
+	// lookups keep the values of the last read until the next load
+	writeProjectedVolume(t, dir, "2024_02", map[string]string{
+		"LOG_LEVEL": "info",
+		"RATE":      "10",
+	})
+	value, _ := source.Lookup("LOG_LEVEL")
+	assert.Equal(t, "debug", value)
+
+	cfg, err = loader.Load()
+	assert.Nil(t, err)
+	assert.Equal(t, "info", cfg.LogLevel)
+	assert.Equal(t, 10, cfg.Rate)
+	assert.Equal(t, []string{"LOG_LEVEL", "RATE"}, source.Keys())
+}
+
+func TestDirSourceErrors(t *testing.T) {
+	_, err := NewDirSource("testdata/fake", false)
+	if assert.Error(t, err) {
+		assert.Equal(t, "directory does not exist: testdata/fake", err.Error())
+	}
+	_, err = NewDirSource("testdata/.uri", false)
+	if assert.Error(t, err) {
+		assert.Equal(t, "directory source is not a directory: testdata/.uri", err.Error())
+	}
+}
+
+type testDirReload struct {
+	LogLevel string
+	Rate     int `env:"RATE,optional"`
+}
+
+type testDirSource struct {
+	DbPassword string
+	LogLevel   string
+}
+
+func TestLoadFromEnvDirSource(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeProjectedVolume(t, dir, "2024_01", map[string]string{
+		"db-password": "hunter2\n",
+		"log-level":   "debug",
+	})
+	source, err := NewDirSource(dir, false)
+	assert.Nil(t, err)
+	cfg, err := LoadFromEnv(Config[testDirSource]{
+		UseEnvFile: false,
+		Sources:    []Source{MapSource{"LOG_LEVEL": "info"}, source},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "hunter2", cfg.DbPassword)
+	assert.Equal(t, "info", cfg.LogLevel)
+}
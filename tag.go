@@ -13,30 +13,63 @@ type tag struct {
 
 // ConfigTag represents the name, flags and settings on the struct field.
 type ConfigTag struct {
-	Name      string  // name of the environment variable
-	Validator *string // field validator name
-	Optional  bool    // is the environment variable optional?
-	Default   *string // default value to use if the environment variable does not exist
-	Unset     bool    // clear the environment variable after load?
+	Name              string   // name of the environment variable
+	Validators        []string // field validator expressions (like ip|hostport or !file) run in order
+	Optional          bool     // is the environment variable optional?
+	Default           *string  // default value to use if the environment variable does not exist
+	Unset             bool     // clear the environment variable after load?
+	Prefix            *string  // prefix for the fields of a nested struct (otherwise NAME_)
+	File              bool     // is the environment variable the path to a file containing the value?
+	Static            bool     // reject reloads that change this field?
+	Separator         *string  // separator between the elements of a slice or the pairs of a map (otherwise ",")
+	Quoted            bool     // can the elements of a slice or the pairs of a map be quoted ("a,b") like CSV?
+	KeyValueSeparator *string  // separator between the key and value of a map (otherwise ":")
+	Format            *string  // encoding of the value (json, base64 or hex)
+	Description       string   // help text for the command-line flag (see [BindFlags])
+	Min               *string  // minimum value (or length) of the parsed value
+	Max               *string  // maximum value (or length) of the parsed value
+	Len               *string  // exact length of the parsed value
+	OneOf             []string // allowed values of the parsed value
+	Regex             *string  // regular expression the value must match
+	RequiredIf        *string  // name and value of the field that makes this field required (like TLS_ENABLED:true)
+	RequiredWith      []string // names of the fields that make this field required when any of them are set
+	OneOfGroup        *string  // name of the group of fields where exactly one must be set
 }
 
 // NewConfigTag will create a new [ConfigTag] with the default values.
 func NewConfigTag(name string) ConfigTag {
 	return ConfigTag{
-		Name:      name,
-		Validator: nil,
-		Optional:  false,
-		Default:   nil,
-		Unset:     false,
+		Name:              name,
+		Validators:        nil,
+		Optional:          false,
+		Default:           nil,
+		Unset:             false,
+		Prefix:            nil,
+		File:              false,
+		Static:            false,
+		Separator:         nil,
+		Quoted:            false,
+		KeyValueSeparator: nil,
+		Format:            nil,
+		Description:       "",
+		Min:               nil,
+		Max:               nil,
+		Len:               nil,
+		OneOf:             nil,
+		Regex:             nil,
+		RequiredIf:        nil,
+		RequiredWith:      nil,
+		OneOfGroup:        nil,
 	}
 }
 
+// parseSetting will split a setting into its name and value at the first "=" (the value can contain "=").
 func parseSetting(expressionStr string) (string, string, error) {
-	parts := strings.Split(expressionStr, "=")
-	if len(parts) != 2 {
+	name, value, found := strings.Cut(expressionStr, "=")
+	if !found || name == "" {
 		return "", "", fmt.Errorf("invalid setting %s: invalid syntax", expressionStr)
 	}
-	return parts[0], parts[1], nil
+	return name, value, nil
 }
 
 func parseTag(tagStr string) (*tag, error) {
@@ -80,6 +113,12 @@ func parseEnvTag(tagStr string) (*ConfigTag, error) {
 			configTag.Optional = true
 		case "unset":
 			configTag.Unset = true
+		case "file":
+			configTag.File = true
+		case "static":
+			configTag.Static = true
+		case "quoted":
+			configTag.Quoted = true
 		default:
 			return nil, fmt.Errorf("invalid env tag: unknown flag %s", flagName)
 		}
@@ -90,9 +129,66 @@ func parseEnvTag(tagStr string) (*ConfigTag, error) {
 		settingValue := settingValue
 		switch settingName {
 		case "validate":
-			configTag.Validator = &settingValue
+			configTag.Validators = strings.Split(settingValue, ";")
 		case "default":
 			configTag.Default = &settingValue
+		case "prefix":
+			if settingValue != "" {
+				if err := verifyEnvName(settingValue); err != nil {
+					return nil, fmt.Errorf("invalid env tag: invalid prefix: %w", err)
+				}
+			}
+			configTag.Prefix = &settingValue
+		case "sep":
+			if settingValue == "" {
+				return nil, fmt.Errorf("invalid env tag: invalid separator: sep must not be empty")
+			}
+			configTag.Separator = &settingValue
+		case "kvsep":
+			if settingValue == "" {
+				return nil, fmt.Errorf("invalid env tag: invalid separator: kvsep must not be empty")
+			}
+			configTag.KeyValueSeparator = &settingValue
+		case "format":
+			switch settingValue {
+			case "json", "base64", "hex":
+			default:
+				return nil, fmt.Errorf("invalid env tag: unknown format %s", settingValue)
+			}
+			configTag.Format = &settingValue
+		case "desc":
+			configTag.Description = settingValue
+		case "min":
+			configTag.Min = &settingValue
+		case "max":
+			configTag.Max = &settingValue
+		case "len":
+			configTag.Len = &settingValue
+		case "oneof":
+			configTag.OneOf = strings.Split(settingValue, "|")
+		case "regex":
+			configTag.Regex = &settingValue
+		case "required_if":
+			name, _, found := strings.Cut(settingValue, ":")
+			if !found {
+				return nil, fmt.Errorf("invalid env tag: invalid required_if: %s must be NAME:value", settingValue)
+			}
+			if err := verifyEnvName(name); err != nil {
+				return nil, fmt.Errorf("invalid env tag: invalid required_if: %w", err)
+			}
+			configTag.RequiredIf = &settingValue
+		case "required_with":
+			configTag.RequiredWith = strings.Split(settingValue, "|")
+			for _, name := range configTag.RequiredWith {
+				if err := verifyEnvName(name); err != nil {
+					return nil, fmt.Errorf("invalid env tag: invalid required_with: %w", err)
+				}
+			}
+		case "oneof_group":
+			if settingValue == "" {
+				return nil, fmt.Errorf("invalid env tag: invalid oneof_group: oneof_group must not be empty")
+			}
+			configTag.OneOfGroup = &settingValue
 		default:
 			return nil, fmt.Errorf("invalid env tag: unknown setting %s", settingName)
 		}
@@ -12,10 +12,20 @@ func TestParseSetting(t *testing.T) {
 		assert.Equal(t, "invalid setting hello: invalid syntax", err.Error())
 	}
 
+	_, _, err = parseSetting("=world")
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid setting =world: invalid syntax", err.Error())
+	}
+
 	key, value, err := parseSetting("hello=world")
 	assert.Nil(t, err)
 	assert.Equal(t, "hello", key)
 	assert.Equal(t, "world", value)
+
+	key, value, err = parseSetting("regex=^a=b$")
+	assert.Nil(t, err)
+	assert.Equal(t, "regex", key)
+	assert.Equal(t, "^a=b$", value)
 }
 
 func TestParseTag(t *testing.T) {
@@ -23,9 +33,9 @@ func TestParseTag(t *testing.T) {
 	if assert.Error(t, err) {
 		assert.Equal(t, "invalid tag: empty tag", err.Error())
 	}
-	_, err = parseTag("hello,flag1,flag2,option==")
+	_, err = parseTag("hello,flag1,flag2,=opt")
 	if assert.Error(t, err) {
-		assert.Equal(t, "invalid tag: invalid setting option==: invalid syntax", err.Error())
+		assert.Equal(t, "invalid tag: invalid setting =opt: invalid syntax", err.Error())
 	}
 	tag, err := parseTag("name,flag1,flag2,option1=opt1,option2=opt2")
 	assert.Nil(t, err)
@@ -55,11 +65,76 @@ func TestParseEnvTag(t *testing.T) {
 		assert.Equal(t, "invalid env tag: unknown setting option1", err.Error())
 	}
 
-	tag, err := parseEnvTag("NAME,optional,unset,default=DEFAULT,validate=validator")
+	_, err = parseEnvTag("NAME,prefix=db")
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid env tag: invalid prefix: invalid environment variable name: db must be [A-Z0-9_]+", err.Error())
+	}
+
+	_, err = parseEnvTag("NAME,sep=")
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid env tag: invalid separator: sep must not be empty", err.Error())
+	}
+
+	_, err = parseEnvTag("NAME,format=yaml")
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid env tag: unknown format yaml", err.Error())
+	}
+
+	tag, err := parseEnvTag("NAME,format=json")
+	assert.Nil(t, err)
+	assert.Equal(t, "json", *tag.Format)
+
+	tag, err = parseEnvTag("NAME,min=1,max=10,len=2,oneof=a|b|c,regex=^[a-z]+$")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", *tag.Min)
+	assert.Equal(t, "10", *tag.Max)
+	assert.Equal(t, "2", *tag.Len)
+	assert.Equal(t, []string{"a", "b", "c"}, tag.OneOf)
+	assert.Equal(t, "^[a-z]+$", *tag.Regex)
+
+	tag, err = parseEnvTag("NAME,regex=^[a-z]+=[0-9]+$,default=a=1")
+	assert.Nil(t, err)
+	assert.Equal(t, "^[a-z]+=[0-9]+$", *tag.Regex)
+	assert.Equal(t, "a=1", *tag.Default)
+
+	_, err = parseEnvTag("NAME,required_if=TLS_ENABLED")
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid env tag: invalid required_if: TLS_ENABLED must be NAME:value", err.Error())
+	}
+
+	_, err = parseEnvTag("NAME,required_with=TLS_CERT|tls")
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid env tag: invalid required_with: invalid environment variable name: tls must be [A-Z0-9_]+", err.Error())
+	}
+
+	tag, err = parseEnvTag("NAME,required_if=TLS_ENABLED:true,required_with=TLS_CERT|TLS_KEY,oneof_group=auth")
+	assert.Nil(t, err)
+	assert.Equal(t, "TLS_ENABLED:true", *tag.RequiredIf)
+	assert.Equal(t, []string{"TLS_CERT", "TLS_KEY"}, tag.RequiredWith)
+	assert.Equal(t, "auth", *tag.OneOfGroup)
+
+	tag, err = parseEnvTag("NAME,sep=;,kvsep=/")
+	assert.Nil(t, err)
+	assert.Equal(t, ";", *tag.Separator)
+	assert.Equal(t, "/", *tag.KeyValueSeparator)
+
+	tag, err = parseEnvTag("NAME,prefix=")
+	assert.Nil(t, err)
+	assert.Equal(t, "", *tag.Prefix)
+
+	tag, err = parseEnvTag("NAME,file")
+	assert.Nil(t, err)
+	assert.Equal(t, true, tag.File)
+
+	tag, err = parseEnvTag("NAME,optional,unset,default=DEFAULT,validate=validator")
 	assert.Nil(t, err)
 	assert.Equal(t, "NAME", tag.Name)
 	assert.Equal(t, true, tag.Optional)
 	assert.Equal(t, true, tag.Unset)
 	assert.Equal(t, "DEFAULT", *tag.Default)
-	assert.Equal(t, "validator", *tag.Validator)
+	assert.Equal(t, []string{"validator"}, tag.Validators)
+
+	tag, err = parseEnvTag("NAME,validate=ip|hostport;!file")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"ip|hostport", "!file"}, tag.Validators)
 }
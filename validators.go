@@ -5,12 +5,46 @@ import (
 	"net"
 	"net/url"
 	"os"
+	"reflect"
 	"strconv"
+	"strings"
 )
 
 // Validator is the type a function must implement to provide string validation on environment variables.
 type Validator = func(envName, value string) error
 
+// TypedValidator[V] is the type a function must implement to validate the parsed value of a field of type V.
+type TypedValidator[V any] func(envName string, value V) error
+
+// ValueValidator is a [TypedValidator] that can be registered in [Config] (see [NewValueValidator]).
+type ValueValidator struct {
+	typ      reflect.Type
+	validate func(envName string, rv reflect.Value) error
+}
+
+// NewValueValidator will wrap validator so it can be registered in [Config].
+func NewValueValidator[V any](validator TypedValidator[V]) ValueValidator {
+	return ValueValidator{
+		typ: reflect.TypeOf((*V)(nil)).Elem(),
+		validate: func(envName string, rv reflect.Value) error {
+			return validator(envName, rv.Interface().(V))
+		},
+	}
+}
+
+// Type will return the type of the values that can be validated.
+func (v ValueValidator) Type() reflect.Type {
+	return v.typ
+}
+
+// StructValidator is implemented by structs that check their own fields once they are loaded (like a rule that
+// ReadTimeout must be less than WriteTimeout).
+type StructValidator interface {
+	Validate() error
+}
+
+var structValidatorType = reflect.TypeOf((*StructValidator)(nil)).Elem()
+
 func validateUri(envName string, value string) error {
 	if _, err := url.ParseRequestURI(value); err != nil {
 		return fmt.Errorf("%s=%s is not a URI: %w", envName, value, err)
@@ -84,3 +118,83 @@ var fieldValidators = map[string]Validator{
 	"file":     validateFile,
 	"dir":      validateDir,
 }
+
+// anyOfError is the error returned when a value fails every validator of an expression like ip|hostport.
+type anyOfError struct {
+	errs []error // the error from each validator
+}
+
+func (e *anyOfError) Error() string {
+	messages := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, " or ")
+}
+
+// Unwrap will return the error from each validator.
+func (e *anyOfError) Unwrap() []error {
+	return e.errs
+}
+
+// compileValidator will compile a validator expression into a single [Validator].
+//
+// The expression is a list of validator names separated by "|" where the value must pass any of them (like
+// ip|hostport). A name prefixed by "!" negates the validator (like !file).
+func compileValidator(validators map[string]Validator, expr string) (Validator, error) {
+	var anyOf []Validator
+	for _, name := range strings.Split(expr, "|") {
+		name, negate := strings.CutPrefix(name, "!")
+		if name == "" {
+			return nil, fmt.Errorf("invalid validator expression: %s", expr)
+		}
+		validator, exists := validators[name]
+		if !exists {
+			return nil, fmt.Errorf("unknown validator: %s", name)
+		}
+		if negate {
+			validator = negateValidator(name, validator)
+		}
+		anyOf = append(anyOf, validator)
+	}
+	if len(anyOf) == 1 {
+		return anyOf[0], nil
+	}
+	return func(envName string, value string) error {
+		var errs []error
+		for _, validator := range anyOf {
+			err := validator(envName, value)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err)
+		}
+		return &anyOfError{errs: errs}
+	}, nil
+}
+
+// negateValidator will create a [Validator] that only passes when validator fails.
+func negateValidator(name string, validator Validator) Validator {
+	return func(envName string, value string) error {
+		if err := validator(envName, value); err == nil {
+			return fmt.Errorf("%s=%s must not pass the %s validator", envName, value, name)
+		}
+		return nil
+	}
+}
+
+// compileValueValidator will compile the [ValueValidator] named name into a check on a field of type t.
+//
+// The field must have the exact type of the validator (named types like type Hosts []string do not match []string)
+// or implement it when the validator takes an interface.
+func compileValueValidator(name string, validator ValueValidator, t reflect.Type) (constraint, error) {
+	if validator.typ == nil {
+		return nil, fmt.Errorf("validator %s was not created with NewValueValidator", name)
+	}
+	if t != validator.typ && !(validator.typ.Kind() == reflect.Interface && t.Implements(validator.typ)) {
+		return nil, fmt.Errorf("validator %s requires a %s but found %s", name, validator.typ, t)
+	}
+	return func(fc *FieldConfig, value string, rv reflect.Value) error {
+		return validator.validate(fc.Name, rv)
+	}, nil
+}
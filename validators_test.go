@@ -2,8 +2,11 @@ package confik
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -45,6 +48,62 @@ func TestLoadFromEnvInvalidValidator(t *testing.T) {
 	}
 }
 
+type testValidatorExpressions struct {
+	Bind    string `env:"BIND,validate=ip|hostport"`
+	LogDir  string `env:"LOG_DIR,validate=!file;dir"`
+	Address string `env:"ADDRESS,validate=uri;!hostport"`
+}
+
+func TestLoadFromEnvValidatorExpressions(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("BIND", "localhost:8080")
+	os.Setenv("LOG_DIR", "testdata")
+	os.Setenv("ADDRESS", "https://google.com")
+	cfg, err := LoadFromEnv(Config[testValidatorExpressions]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "localhost:8080", cfg.Bind)
+
+	os.Setenv("BIND", "10.0.0.1")
+	_, err = LoadFromEnv(Config[testValidatorExpressions]{
+		UseEnvFile: false,
+	})
+	assert.Nil(t, err)
+
+	os.Setenv("BIND", "localhost")
+	os.Setenv("LOG_DIR", "testdata/secrets/db_password")
+	os.Setenv("ADDRESS", "localhost:8080")
+	_, err = LoadFromEnv(Config[testValidatorExpressions]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrValidation)
+		assert.Equal(t, []string{
+			"BIND=localhost is not a valid IP: invalid format or BIND=localhost is not a valid hostport: address localhost: missing port in address",
+			"LOG_DIR=testdata/secrets/db_password must not pass the file validator",
+			"LOG_DIR=testdata/secrets/db_password exists but is not a directory",
+			"ADDRESS=localhost:8080 must not pass the hostport validator",
+		}, loadErrorMessages(t, err))
+	}
+}
+
+func TestCompileValidator(t *testing.T) {
+	_, err := compileValidator(fieldValidators, "ip|")
+	if assert.Error(t, err) {
+		assert.Equal(t, "invalid validator expression: ip|", err.Error())
+	}
+	_, err = compileValidator(fieldValidators, "!nope")
+	if assert.Error(t, err) {
+		assert.Equal(t, "unknown validator: nope", err.Error())
+	}
+	validator, err := compileValidator(fieldValidators, "port|!ip")
+	assert.Nil(t, err)
+	assert.Nil(t, validator("MY_VAR", "80"))
+	assert.Nil(t, validator("MY_VAR", "localhost"))
+	assert.Error(t, validator("MY_VAR", "10.0.0.1"))
+}
+
 func TestValidatorUri(t *testing.T) {
 	err := validateUri("MY_VAR", "my_value")
 	if assert.Error(t, err) {
@@ -134,3 +193,204 @@ func TestValidateDir(t *testing.T) {
 	err = validateDir("MY_VAR", "testdata/")
 	assert.Nil(t, err)
 }
+
+type testValueValidators struct {
+	Timeout  time.Duration  `env:"TIMEOUT,validate=positive"`
+	Interval *time.Duration `env:"INTERVAL,optional,validate=positive"`
+	Website  url.URL        `env:"WEBSITE,validate=uri"`
+}
+
+func positiveDuration(envName string, value time.Duration) error {
+	if value <= 0 {
+		return fmt.Errorf("%s=%s must be positive", envName, value)
+	}
+	return nil
+}
+
+func httpsURL(envName string, value url.URL) error {
+	if value.Scheme != "https" {
+		return fmt.Errorf("%s must use https", envName)
+	}
+	return nil
+}
+
+func TestLoadFromEnvValueValidators(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("TIMEOUT", "5s")
+	os.Setenv("WEBSITE", "https://google.com")
+	cfg := Config[testValueValidators]{
+		UseEnvFile: false,
+		ValueValidators: map[string]ValueValidator{
+			"positive": NewValueValidator(positiveDuration),
+		},
+		TypeValidators: map[reflect.Type]ValueValidator{
+			reflect.TypeOf(url.URL{}): NewValueValidator(httpsURL),
+		},
+	}
+	loaded, err := LoadFromEnv(cfg)
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Second, loaded.Timeout)
+	assert.Nil(t, loaded.Interval)
+
+	os.Setenv("TIMEOUT", "-1s")
+	os.Setenv("INTERVAL", "0s")
+	os.Setenv("WEBSITE", "http://google.com")
+	_, err = LoadFromEnv(cfg)
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrValidation)
+		assert.Equal(t, []string{
+			"TIMEOUT=-1s must be positive",
+			"INTERVAL=0s must be positive",
+			"WEBSITE must use https",
+		}, loadErrorMessages(t, err))
+	}
+}
+
+func TestNewLoaderInvalidValueValidator(t *testing.T) {
+	_, err := NewLoader(Config[struct {
+		Name string `env:"NAME,validate=positive"`
+	}]{
+		UseEnvFile: false,
+		ValueValidators: map[string]ValueValidator{
+			"positive": NewValueValidator(positiveDuration),
+		},
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrInvalidTag)
+		assert.Equal(t, "invalid tag on field Name: validator positive requires a time.Duration but found string", err.Error())
+	}
+}
+
+type testHosts []string
+
+type testMode string
+
+func (m testMode) String() string {
+	return string(m)
+}
+
+func TestNewLoaderValueValidatorNamedType(t *testing.T) {
+	nonEmpty := NewValueValidator(func(envName string, value []string) error {
+		return nil
+	})
+	_, err := NewLoader(Config[struct {
+		Hosts testHosts `env:"HOSTS,validate=nonempty"`
+	}]{
+		UseEnvFile:      false,
+		ValueValidators: map[string]ValueValidator{"nonempty": nonEmpty},
+	})
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrInvalidTag)
+		assert.Equal(t, "invalid tag on field Hosts: validator nonempty requires a []string but found confik.testHosts", err.Error())
+	}
+
+	// validators of an interface run on every type that implements it
+	os.Clearenv()
+	os.Setenv("MODE", "warn")
+	stringer := NewValueValidator(func(envName string, value fmt.Stringer) error {
+		if value.String() != "warn" {
+			return fmt.Errorf("%s=%s must be warn", envName, value)
+		}
+		return nil
+	})
+	cfg := Config[struct {
+		Mode testMode `env:"MODE,validate=stringer"`
+	}]{
+		UseEnvFile:      false,
+		ValueValidators: map[string]ValueValidator{"stringer": stringer},
+	}
+	_, err = LoadFromEnv(cfg)
+	assert.Nil(t, err)
+	os.Setenv("MODE", "safe")
+	_, err = LoadFromEnv(cfg)
+	if assert.Error(t, err) {
+		assert.Equal(t, "MODE=safe must be warn", err.Error())
+	}
+}
+
+type testTimeouts struct {
+	Read  time.Duration `env:"READ"`
+	Write time.Duration `env:"WRITE"`
+}
+
+func (t *testTimeouts) Validate() error {
+	if t.Read >= t.Write {
+		return fmt.Errorf("read timeout %s must be less than write timeout %s", t.Read, t.Write)
+	}
+	return nil
+}
+
+type testStructValidator struct {
+	Name     string
+	Timeouts testTimeouts
+}
+
+func (t testStructValidator) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("name must not be empty")
+	}
+	return nil
+}
+
+func TestLoadFromEnvStructValidator(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("NAME", "app")
+	os.Setenv("TIMEOUTS_READ", "1s")
+	os.Setenv("TIMEOUTS_WRITE", "2s")
+	cfg := Config[testStructValidator]{
+		UseEnvFile: false,
+	}
+	_, err := LoadFromEnv(cfg)
+	assert.Nil(t, err)
+
+	os.Setenv("NAME", "")
+	os.Setenv("TIMEOUTS_READ", "3s")
+	_, err = LoadFromEnv(cfg)
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrValidation)
+		var loadErr *LoadError
+		if assert.ErrorAs(t, err, &loadErr) && assert.Equal(t, 1, len(loadErr.Errors)) {
+			assert.Equal(t, "Timeouts", loadErr.Errors[0].Field)
+			assert.Equal(t, "read timeout 3s must be less than write timeout 2s", loadErr.Errors[0].Error())
+		}
+	}
+
+	os.Setenv("TIMEOUTS_READ", "1s")
+	_, err = LoadFromEnv(cfg)
+	if assert.Error(t, err) {
+		var fieldErr *FieldError
+		if assert.ErrorAs(t, err, &fieldErr) {
+			assert.Equal(t, "", fieldErr.Field)
+			assert.Equal(t, "name must not be empty", fieldErr.Error())
+		}
+	}
+
+	// the method is not called when a field of the struct (or a nested struct) failed to load
+	os.Setenv("TIMEOUTS_READ", "soon")
+	_, err = LoadFromEnv(cfg)
+	if assert.Error(t, err) {
+		var loadErr *LoadError
+		if assert.ErrorAs(t, err, &loadErr) && assert.Equal(t, 1, len(loadErr.Errors)) {
+			assert.ErrorIs(t, loadErr.Errors[0], ErrParse)
+		}
+	}
+}
+
+func TestLoadFromEnvStructValidatorList(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("SERVERS_0_READ", "1s")
+	os.Setenv("SERVERS_0_WRITE", "2s")
+	os.Setenv("SERVERS_1_READ", "2s")
+	os.Setenv("SERVERS_1_WRITE", "1s")
+	_, err := LoadFromEnv(Config[struct {
+		Servers []testTimeouts
+	}]{
+		UseEnvFile: false,
+	})
+	if assert.Error(t, err) {
+		var fieldErr *FieldError
+		if assert.ErrorAs(t, err, &fieldErr) {
+			assert.Equal(t, "Servers[1]", fieldErr.Field)
+		}
+	}
+}
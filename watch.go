@@ -0,0 +1,233 @@
+package confik
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// WatchOptions is the configuration for how a [Watcher] reloads.
+type WatchOptions struct {
+	Interval time.Duration // how often to reload (0 disables polling)
+	Signals  []os.Signal   // signals that trigger a reload (SIGHUP by default, except on js)
+	OnError  func(error)   // called when a reload is rejected (the last good config is kept)
+}
+
+// DefaultWatchOptions will create a new [WatchOptions] with the default values.
+func DefaultWatchOptions() WatchOptions {
+	return WatchOptions{
+		Interval: 30 * time.Second,
+		Signals:  defaultWatchSignals(),
+		OnError:  nil,
+	}
+}
+
+// Change is a new configuration published by a [Watcher].
+type Change[T any] struct {
+	Old    *T       // the previous configuration
+	New    *T       // the new configuration
+	Fields []string // dotted paths of the fields that changed
+}
+
+// Watcher will reload a T from the environment and publish it when it changes.
+//
+// Reloads that fail to load, or that change a field with the "static" flag, are rejected and the last good
+// configuration is kept. The environment file is read on each reload (see [NewWatcher]).
+type Watcher[T any] struct {
+	loader      *Loader[T]
+	opts        WatchOptions
+	current     atomic.Pointer[T]
+	reloadMu    sync.Mutex // serializes reloads
+	mu          sync.Mutex // protects the subscribers
+	subscribers map[int]func(Change[T])
+	nextId      int
+	stop        chan struct{}
+	stopOnce    sync.Once
+	done        chan struct{}
+}
+
+// NewWatcher will load a T from the environment and start watching for changes.
+//
+// The initial load must succeed. Call [Watcher.Close] to stop watching.
+//
+// When UseEnvFile is set [Config.EnvFileOverlay] is always enabled so changes to the environment file are not
+// hidden by the values a previous load added to the process environment.
+func NewWatcher[T any](cfg Config[T], opts WatchOptions) (*Watcher[T], error) {
+	if cfg.UseEnvFile {
+		cfg.EnvFileOverlay = true
+	}
+	loader, err := NewLoader(cfg)
+	if err != nil {
+		return nil, err
+	}
+	value, err := loader.Load()
+	if err != nil {
+		return nil, err
+	}
+	w := &Watcher[T]{
+		loader:      loader,
+		opts:        opts,
+		subscribers: make(map[int]func(Change[T])),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	w.current.Store(value)
+
+	// register for signals before returning so none are missed
+	var signals chan os.Signal
+	if len(opts.Signals) > 0 {
+		signals = make(chan os.Signal, 1)
+		signal.Notify(signals, opts.Signals...)
+	}
+	go w.watch(signals)
+	return w, nil
+}
+
+// Get will return the current configuration.
+//
+// The returned value is shared and must not be modified.
+func (w *Watcher[T]) Get() *T {
+	return w.current.Load()
+}
+
+// Subscribe will call fn with each new configuration.
+//
+// The returned function removes the subscription.
+func (w *Watcher[T]) Subscribe(fn func(Change[T])) func() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := w.nextId
+	w.nextId++
+	w.subscribers[id] = fn
+	return func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		delete(w.subscribers, id)
+	}
+}
+
+// Reload will load a T from the environment and publish it if any of the fields changed.
+//
+// The returned [Change] is nil if nothing changed. An error is returned if the reload was rejected.
+//
+// Subscribers are called after the reload has finished so they can call Reload themselves. Subscribers of reloads
+// that run at the same time can be called concurrently.
+func (w *Watcher[T]) Reload() (*Change[T], error) {
+	change, err := w.reload()
+	if change == nil {
+		return nil, err
+	}
+
+	// call the subscribers without holding the locks so they can reload or unsubscribe
+	w.mu.Lock()
+	subscribers := make([]func(Change[T]), 0, len(w.subscribers))
+	for _, fn := range w.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	w.mu.Unlock()
+	for _, fn := range subscribers {
+		fn(*change)
+	}
+	return change, nil
+}
+
+// reload will load a T from the environment and store it if any of the fields changed (see [Watcher.Reload]).
+func (w *Watcher[T]) reload() (*Change[T], error) {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	value, err := w.loader.Load()
+	if err != nil {
+		return nil, fmt.Errorf("reload rejected: %w", err)
+	}
+
+	old := w.current.Load()
+	changed := changedFields(w.loader.plan, reflect.ValueOf(old).Elem(), reflect.ValueOf(value).Elem())
+	if len(changed) == 0 {
+		return nil, nil
+	}
+
+	// static fields can only be set when the program starts
+	var static []string
+	fields := make([]string, len(changed))
+	for i, fc := range changed {
+		fields[i] = fc.Field
+		if fc.Static {
+			static = append(static, fmt.Sprintf("%s (%s)", fc.Field, fc.Name))
+		}
+	}
+	if len(static) > 0 {
+		return nil, fmt.Errorf("reload rejected: static fields changed: %s", strings.Join(static, ", "))
+	}
+
+	w.current.Store(value)
+	return &Change[T]{
+		Old:    old,
+		New:    value,
+		Fields: fields,
+	}, nil
+}
+
+// Close will stop watching for changes.
+//
+// Close waits for a reload in progress to finish, so it must not be called by a subscriber (call it from another
+// goroutine instead).
+func (w *Watcher[T]) Close() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
+
+// watch will reload on each tick of the interval or signal until the watcher is closed.
+func (w *Watcher[T]) watch(signals chan os.Signal) {
+	defer close(w.done)
+	if signals != nil {
+		defer signal.Stop(signals)
+	}
+
+	var tick <-chan time.Time
+	if w.opts.Interval > 0 {
+		ticker := time.NewTicker(w.opts.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-tick:
+		case <-signals:
+		}
+		if _, err := w.Reload(); err != nil && w.opts.OnError != nil {
+			w.opts.OnError(err)
+		}
+	}
+}
+
+// changedFields will compare the fields of two structs and return the config of each field that differs.
+//
+// Nested structs are compared field by field using the plan of the loader.
+func changedFields(plan *structPlan, old reflect.Value, new reflect.Value) []*FieldConfig {
+	var changed []*FieldConfig
+	for _, fp := range plan.fields {
+		ov, nv := old.Field(fp.index), new.Field(fp.index)
+		if fp.nested != nil && fp.typ.Kind() == reflect.Struct {
+			changed = append(changed, changedFields(fp.nested, ov, nv)...)
+			continue
+		}
+		if fp.nested != nil && fp.typ.Kind() == reflect.Pointer && !ov.IsNil() && !nv.IsNil() {
+			changed = append(changed, changedFields(fp.nested, ov.Elem(), nv.Elem())...)
+			continue
+		}
+		if ov.CanInterface() && !reflect.DeepEqual(ov.Interface(), nv.Interface()) {
+			changed = append(changed, fp.FieldConfig)
+		}
+	}
+	return changed
+}
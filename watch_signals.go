@@ -0,0 +1,13 @@
+//go:build !js
+
+package confik
+
+import (
+	"os"
+	"syscall"
+)
+
+// defaultWatchSignals will get the signals that trigger a reload by default.
+func defaultWatchSignals() []os.Signal {
+	return []os.Signal{syscall.SIGHUP}
+}
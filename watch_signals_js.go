@@ -0,0 +1,10 @@
+//go:build js
+
+package confik
+
+import "os"
+
+// defaultWatchSignals will get the signals that trigger a reload by default (there are no signals on js).
+func defaultWatchSignals() []os.Signal {
+	return nil
+}
@@ -0,0 +1,44 @@
+//go:build !js
+
+package confik
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcherSignal(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("LOG_LEVEL", "info")
+	os.Setenv("RATE_LIMIT", "100")
+	os.Setenv("DATABASE_HOST", "db")
+	w, err := NewWatcher(Config[testWatch]{
+		UseEnvFile: false,
+	}, WatchOptions{
+		Signals: []os.Signal{syscall.SIGHUP},
+	})
+	assert.Nil(t, err)
+	defer w.Close()
+
+	changes := make(chan Change[testWatch], 1)
+	w.Subscribe(func(change Change[testWatch]) {
+		changes <- change
+	})
+	os.Setenv("LOG_LEVEL", "debug")
+
+	process, err := os.FindProcess(os.Getpid())
+	assert.Nil(t, err)
+	if err := process.Signal(syscall.SIGHUP); err != nil {
+		t.Skipf("unable to send SIGHUP: %s", err)
+	}
+	select {
+	case change := <-changes:
+		assert.Equal(t, []string{"LogLevel"}, change.Fields)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+}
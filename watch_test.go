@@ -0,0 +1,202 @@
+package confik
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testWatchDatabase struct {
+	Host string `env:"HOST,static"`
+	Pool int    `env:"POOL,default=10"`
+}
+
+type testWatch struct {
+	LogLevel  string
+	RateLimit int
+	Database  testWatchDatabase
+}
+
+func TestWatcherReload(t *testing.T) {
+	source := MapSource{
+		"LOG_LEVEL":     "info",
+		"RATE_LIMIT":    "100",
+		"DATABASE_HOST": "db",
+	}
+	w, err := NewWatcher(Config[testWatch]{
+		UseEnvFile: false,
+		Sources:    []Source{source},
+	}, WatchOptions{})
+	assert.Nil(t, err)
+	defer w.Close()
+	first := w.Get()
+	assert.Equal(t, "info", first.LogLevel)
+
+	var changes []Change[testWatch]
+	unsubscribe := w.Subscribe(func(change Change[testWatch]) {
+		changes = append(changes, change)
+	})
+
+	// nothing changed
+	change, err := w.Reload()
+	assert.Nil(t, err)
+	assert.Nil(t, change)
+
+	source["LOG_LEVEL"] = "debug"
+	source["DATABASE_POOL"] = "20"
+	change, err = w.Reload()
+	assert.Nil(t, err)
+	if assert.NotNil(t, change) {
+		assert.Equal(t, []string{"LogLevel", "Database.Pool"}, change.Fields)
+		assert.Equal(t, first, change.Old)
+		assert.Equal(t, "debug", change.New.LogLevel)
+	}
+	assert.Equal(t, "debug", w.Get().LogLevel)
+	assert.Equal(t, 20, w.Get().Database.Pool)
+	assert.Equal(t, "info", first.LogLevel)
+	assert.Equal(t, 1, len(changes))
+
+	unsubscribe()
+	source["RATE_LIMIT"] = "50"
+	_, err = w.Reload()
+	assert.Nil(t, err)
+	assert.Equal(t, 50, w.Get().RateLimit)
+	assert.Equal(t, 1, len(changes))
+}
+
+func TestWatcherReloadFromSubscriber(t *testing.T) {
+	source := MapSource{
+		"LOG_LEVEL":     "info",
+		"RATE_LIMIT":    "100",
+		"DATABASE_HOST": "db",
+	}
+	w, err := NewWatcher(Config[testWatch]{
+		UseEnvFile: false,
+		Sources:    []Source{source},
+	}, WatchOptions{})
+	assert.Nil(t, err)
+	defer w.Close()
+
+	// a subscriber can reload without deadlocking
+	var reloads []*Change[testWatch]
+	w.Subscribe(func(change Change[testWatch]) {
+		reloaded, err := w.Reload()
+		assert.Nil(t, err)
+		reloads = append(reloads, reloaded)
+	})
+	source["LOG_LEVEL"] = "debug"
+	_, err = w.Reload()
+	assert.Nil(t, err)
+	assert.Equal(t, []*Change[testWatch]{nil}, reloads)
+}
+
+func TestWatcherReloadEnvFile(t *testing.T) {
+	os.Clearenv()
+	path := filepath.Join(t.TempDir(), ".env")
+	assert.Nil(t, os.WriteFile(path, []byte("LOG_LEVEL=info\nRATE_LIMIT=100\nDATABASE_HOST=db\n"), 0o644))
+	cfg := DefaultConfig[testWatch]()
+	cfg.EnvFilePath = path
+	w, err := NewWatcher(cfg, WatchOptions{})
+	assert.Nil(t, err)
+	defer w.Close()
+	assert.Equal(t, "info", w.Get().LogLevel)
+
+	// the values in the file are not added to the process environment
+	_, exists := os.LookupEnv("LOG_LEVEL")
+	assert.False(t, exists)
+
+	assert.Nil(t, os.WriteFile(path, []byte("LOG_LEVEL=debug\nRATE_LIMIT=100\nDATABASE_HOST=db\n"), 0o644))
+	change, err := w.Reload()
+	assert.Nil(t, err)
+	if assert.NotNil(t, change) {
+		assert.Equal(t, []string{"LogLevel"}, change.Fields)
+	}
+	assert.Equal(t, "debug", w.Get().LogLevel)
+}
+
+func TestWatcherReloadRejected(t *testing.T) {
+	source := MapSource{
+		"LOG_LEVEL":     "info",
+		"RATE_LIMIT":    "100",
+		"DATABASE_HOST": "db",
+	}
+	w, err := NewWatcher(Config[testWatch]{
+		UseEnvFile: false,
+		Sources:    []Source{source},
+	}, WatchOptions{})
+	assert.Nil(t, err)
+	defer w.Close()
+
+	// invalid values keep the last good config
+	source["LOG_LEVEL"] = "debug"
+	source["RATE_LIMIT"] = "many"
+	_, err = w.Reload()
+	if assert.Error(t, err) {
+		assert.ErrorIs(t, err, ErrParse)
+		assert.Equal(t, "reload rejected: RATE_LIMIT=many is not a valid int: strconv.ParseInt: parsing \"many\": invalid syntax", err.Error())
+	}
+	assert.Equal(t, "info", w.Get().LogLevel)
+
+	// static fields can not change
+	source["RATE_LIMIT"] = "100"
+	source["DATABASE_HOST"] = "other"
+	_, err = w.Reload()
+	if assert.Error(t, err) {
+		assert.Equal(t, "reload rejected: static fields changed: Database.Host (DATABASE_HOST)", err.Error())
+	}
+	assert.Equal(t, "info", w.Get().LogLevel)
+	assert.Equal(t, "db", w.Get().Database.Host)
+}
+
+func TestWatcherInitialLoadError(t *testing.T) {
+	_, err := NewWatcher(Config[testWatch]{
+		UseEnvFile: false,
+		Sources:    []Source{MapSource{}},
+	}, WatchOptions{})
+	assert.ErrorIs(t, err, ErrMissing)
+}
+
+func TestWatcherPoll(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("LOG_LEVEL", "info")
+	os.Setenv("RATE_LIMIT", "100")
+	os.Setenv("DATABASE_HOST", "db")
+	errs := make(chan error, 1)
+	w, err := NewWatcher(Config[testWatch]{
+		UseEnvFile: false,
+	}, WatchOptions{
+		Interval: time.Millisecond,
+		OnError: func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		},
+	})
+	assert.Nil(t, err)
+	defer w.Close()
+
+	changes := make(chan Change[testWatch], 1)
+	w.Subscribe(func(change Change[testWatch]) {
+		changes <- change
+	})
+	os.Setenv("LOG_LEVEL", "debug")
+	select {
+	case change := <-changes:
+		assert.Equal(t, "debug", change.New.LogLevel)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+
+	os.Setenv("RATE_LIMIT", "many")
+	select {
+	case err := <-errs:
+		assert.True(t, errors.Is(err, ErrParse))
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reload error")
+	}
+}